@@ -0,0 +1,196 @@
+// 配置表标注(annotate 命令)，在工作簿中写入数据验证、输入提示和错误标记
+
+package core
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	annotateAuthor = "excelparser check"     // 错误批注的作者(批注文本以 "作者:" 开头，用于识别和清除)
+	annotateMarker = `ISTEXT("excelparser")` // 错误高亮条件格式的公式(用于识别和清除)
+)
+
+// 标注结果
+type AnnotateResult struct {
+	Validations int      // 数据验证数量(下拉列表、取值范围、输入提示)
+	ErrorCells  int      // 标记的错误单元格数量
+	Errors      []string // 配置表检查错误
+}
+
+// AnnotateXlsx 在配置表工作簿中写入标注(直接修改源文件)
+// 为每个字段列添加数据验证(bool/枚举下拉列表、取值范围)和输入提示(描述、批注)，
+// 检查失败的配置单元格添加错误批注并高亮显示(条件格式，不修改单元格样式)。
+// 表头和配置值不变，再次标注时先清除上次的标注；clear 为 true 时只清除标注
+func AnnotateXlsx(path string, clear bool) (*AnnotateResult, error) {
+	if strings.ToLower(filepath.Ext(path)) != ".xlsx" {
+		return nil, errors.New("只支持 xlsx 文件")
+	}
+	result := &AnnotateResult{}
+	var x *Xlsx
+	if !clear {
+		var err error
+		if x, err = LoadXlsx(path); err != nil {
+			return nil, err
+		}
+		result.Errors = x.Errors
+	}
+
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sheet, vertical := findDataSheet(f)
+	if len(sheet) == 0 {
+		return nil, errNoDataSheet
+	}
+	if err := clearAnnotations(f, sheet, vertical); err != nil {
+		return nil, err
+	}
+	if clear {
+		return result, f.Save()
+	}
+
+	// 数据验证和输入提示
+	var walk func(field *Field) error
+	walk = func(field *Field) error {
+		if field.Index >= 0 {
+			dv := typeValidation(field.Type, field.Rname, fieldPrompt(field.Desc, field.Comment))
+			if dv != nil {
+				dv.Sqref = valueSqref(field.Index, vertical)
+				if err := f.AddDataValidation(sheet, dv); err != nil {
+					return err
+				}
+				result.Validations++
+			}
+		}
+		for _, v := range append(field.Keys, field.Vals...) {
+			if err := walk(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(x.RootField); err != nil {
+		return nil, err
+	}
+
+	// 错误单元格(只标记配置行，同一个单元格的多个错误合并)
+	cells := make([]string, 0)
+	msgs := make(map[string][]string)
+	for _, ce := range x.CellErrors {
+		col, row := splitAxis(ce.Cell)
+		if ternary(vertical, col, row) <= HeadLineNum {
+			continue
+		}
+		if _, ok := msgs[ce.Cell]; !ok {
+			cells = append(cells, ce.Cell)
+		}
+		msgs[ce.Cell] = append(msgs[ce.Cell], ce.Text)
+	}
+	if len(cells) > 0 {
+		comments, err := f.GetComments(sheet)
+		if err != nil {
+			return nil, err
+		}
+		commented := make(map[string]bool)
+		for _, c := range comments {
+			commented[c.Cell] = true
+		}
+		for _, cell := range cells {
+			if commented[cell] {
+				// 不覆盖已有的批注
+				continue
+			}
+			err := f.AddComment(sheet, excelize.Comment{
+				Cell:   cell,
+				Author: annotateAuthor,
+				Paragraph: []excelize.RichTextRun{
+					{Text: annotateAuthor + ":\n", Font: &excelize.Font{Bold: true}},
+					{Text: strings.Join(msgs[cell], "\n")},
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		style, err := f.NewConditionalStyle(&excelize.Style{
+			Font: &excelize.Font{Color: "9C0006"},
+			Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFC7CE"}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = f.SetConditionalFormat(sheet, strings.Join(cells, " "), []excelize.ConditionalFormatOptions{
+			{Type: "formula", Criteria: annotateMarker, Format: &style},
+		})
+		if err != nil {
+			return nil, err
+		}
+		result.ErrorCells = len(cells)
+	}
+	return result, f.Save()
+}
+
+// 清除上次的标注：错误批注、错误高亮和字段列的数据验证
+func clearAnnotations(f *excelize.File, sheet string, vertical bool) error {
+	comments, err := f.GetComments(sheet)
+	if err != nil {
+		return err
+	}
+	for _, c := range comments {
+		// excelize 添加已有作者的批注时作者 id 错误，不能按作者识别
+		if strings.HasPrefix(commentText(c), annotateAuthor+":\n") {
+			if err := f.DeleteComment(sheet, c.Cell); err != nil {
+				return err
+			}
+		}
+	}
+
+	formats, err := f.GetConditionalFormats(sheet)
+	if err != nil {
+		return err
+	}
+	for sqref, opts := range formats {
+		for _, opt := range opts {
+			if opt.Type == "formula" && opt.Criteria == annotateMarker {
+				if err := f.UnsetConditionalFormat(sheet, sqref); err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+
+	// 删除区域为整个字段列的数据验证(与 valueSqref 一致)
+	// 按区域删除需要展开区域中的所有单元格(整列有一百多万个)，所以全部删除后再添加其他数据验证
+	dvs, err := f.GetDataValidations(sheet)
+	if err != nil || len(dvs) == 0 {
+		return err
+	}
+	if err := f.DeleteDataValidation(sheet); err != nil {
+		return err
+	}
+	// 扩展列表(x14)中的数据验证不会被删除，排在返回结果的最后
+	exts, err := f.GetDataValidations(sheet)
+	if err != nil {
+		return err
+	}
+	for _, dv := range dvs[:len(dvs)-len(exts)] {
+		start, _, _ := strings.Cut(dv.Sqref, ":")
+		col, row := splitAxis(start)
+		if index := ternary(vertical, row, col) - 1; index >= 0 && dv.Sqref == valueSqref(index, vertical) {
+			continue
+		}
+		if err := f.AddDataValidation(sheet, dv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+// 合并导出
+
+package core
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// 合并文件魔数(csharp)
+const BundleMagic = "EPB1"
+
+// 合并文件条目
+type bundleEntry struct {
+	name string
+	data []byte
+}
+
+// 合并文件扩展名，不支持合并的格式返回空
+func bundleExt(format string) string {
+	if format == "toml" {
+		return ""
+	}
+	return outputExt(format)
+}
+
+// WriteBundle 将 outdir 下所有配置表的导出文件合并为一个文件(outdir/合并名.扩展名)
+// 合并文件由磁盘上的导出文件生成，因此包含本次未修改(跳过导出)的配置表
+// 导出文件已压缩、加密时先还原再合并，合并文件整体重新打包
+func WriteBundle(outdir, format string) error {
+	ext := bundleExt(format)
+	if len(ext) == 0 {
+		return nil
+	}
+	if strings.ContainsAny(GFlags.Bundle, `/\.`) {
+		return fmt.Errorf("合并文件名[%s]不合法", GFlags.Bundle)
+	}
+
+	entries := make([]bundleEntry, 0, len(XlsxList))
+	for _, x := range XlsxList {
+		if x.OutName == GFlags.Bundle {
+			return fmt.Errorf("合并文件名 %s 与配置表 %s 的导出名冲突", GFlags.Bundle, x.Name)
+		}
+		data, err := os.ReadFile(fmt.Sprintf("%s%s.%s", outdir, x.OutName, ext))
+		if err != nil {
+			// 配置表没有导出到该目标
+			continue
+		}
+		if data, err = unpackData(data); err != nil {
+			return fmt.Errorf("%s 合并失败: %v", x.Name, err)
+		}
+		entries = append(entries, bundleEntry{x.OutName, data})
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var out []byte
+	var err error
+	switch format {
+	case "lua":
+		out = bundleLua(entries)
+	case "json":
+		out = bundleJson(entries)
+	case "yaml":
+		out = bundleYaml(entries)
+	case "csharp":
+		out, err = bundleBinary(entries)
+	}
+	if err == nil && packEnabled() {
+		out, err = packData(out)
+	}
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fmt.Sprintf("%s%s.%s", outdir, GFlags.Bundle, ext), out, 0o666)
+}
+
+// lua 合并文件返回所有配置表，配置表在第一次访问时加载
+func bundleLua(entries []bundleEntry) []byte {
+	var b bytes.Buffer
+	b.WriteString("-- Auto generated by excelparser. DO NOT EDIT!\n\n")
+	b.WriteString("local loaders = {}\n")
+	for _, e := range entries {
+		b.WriteString(fmt.Sprintf("\nloaders[%s] = function()\n", formatString(e.name)))
+		b.Write(bytes.TrimRight(e.data, "\n"))
+		b.WriteString("\nend\n")
+	}
+	b.WriteString(`
+return setmetatable({}, {__index = function(t, name)
+  local loader = loaders[name]
+  if loader then
+    local v = loader()
+    rawset(t, name, v)
+    return v
+  end
+end})
+`)
+	return b.Bytes()
+}
+
+// json 合并文件为以导出名为键的对象
+func bundleJson(entries []bundleEntry) []byte {
+	var b bytes.Buffer
+	b.WriteString("{")
+	for i, e := range entries {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		b.WriteString(ternary(GFlags.Compact, "", "\n"))
+		b.WriteString(fmt.Sprintf("%q:", e.name))
+		b.Write(bytes.TrimSpace(e.data))
+	}
+	b.WriteString(ternary(GFlags.Compact, "}", "\n}\n"))
+	return b.Bytes()
+}
+
+// yaml 合并文件为以导出名为键的映射
+func bundleYaml(entries []bundleEntry) []byte {
+	var b bytes.Buffer
+	b.WriteString("# Auto generated by excelparser. DO NOT EDIT!\n")
+	for _, e := range entries {
+		b.WriteString(e.name + ":\n")
+		for _, line := range strings.Split(strings.TrimRight(string(e.data), "\n"), "\n") {
+			if strings.HasPrefix(line, "#") {
+				continue
+			}
+			b.WriteString("  " + line + "\n")
+		}
+	}
+	return b.Bytes()
+}
+
+// 二进制合并文件格式:
+// 魔数(4字节) | 索引长度(uint32, 小端) | 索引(MessagePack 数组: [[导出名, 偏移, 长度], ...]) | 数据
+// 偏移为相对数据区起始位置的偏移
+func bundleBinary(entries []bundleEntry) ([]byte, error) {
+	index := make([][]any, 0, len(entries))
+	offset := 0
+	for _, e := range entries {
+		index = append(index, []any{e.name, offset, len(e.data)})
+		offset += len(e.data)
+	}
+	header, err := msgpack.Marshal(index)
+	if err != nil {
+		return nil, errors.New("合并文件索引生成失败: " + err.Error())
+	}
+
+	var b bytes.Buffer
+	b.WriteString(BundleMagic)
+	binary.Write(&b, binary.LittleEndian, uint32(len(header)))
+	b.Write(header)
+	for _, e := range entries {
+		b.Write(e.data)
+	}
+	return b.Bytes(), nil
+}
+
+// WriteTableBundleCSharp 在 outdir 下生成读取二进制合并文件的 TableBundle.cs
+func WriteTableBundleCSharp(outdir string) error {
+	code := `// Auto generated by excelparser. DO NOT EDIT!
+using System;
+using System.Collections.Generic;
+using MessagePack;
+
+namespace Game.Table
+{
+    /// <summary>配置表合并文件(` + BundleMagic + `)读取</summary>
+    public sealed class TableBundle
+    {
+        private readonly byte[] _data;
+        private readonly int _dataStart;
+        private readonly Dictionary<string, (int offset, int length)> _index = new Dictionary<string, (int, int)>();
+
+        public TableBundle(byte[] data)
+        {
+            if (data.Length < 8 || data[0] != '` + BundleMagic[0:1] + `' || data[1] != '` + BundleMagic[1:2] + `' || data[2] != '` + BundleMagic[2:3] + `' || data[3] != '` + BundleMagic[3:4] + `')
+            {
+                throw new FormatException("invalid table bundle");
+            }
+            int headerLen = BitConverter.ToInt32(data, 4);
+            var entries = MessagePackSerializer.Deserialize<object[][]>(new ReadOnlyMemory<byte>(data, 8, headerLen));
+            foreach (var e in entries)
+            {
+                _index[(string)e[0]] = (Convert.ToInt32(e[1]), Convert.ToInt32(e[2]));
+            }
+            _data = data;
+            _dataStart = 8 + headerLen;
+        }
+
+        public IEnumerable<string> Names => _index.Keys;
+
+        public bool TryGet(string name, out ReadOnlyMemory<byte> bytes)
+        {
+            if (_index.TryGetValue(name, out var e))
+            {
+                bytes = new ReadOnlyMemory<byte>(_data, _dataStart + e.offset, e.length);
+                return true;
+            }
+            bytes = default;
+            return false;
+        }
+
+        public T Load<T>(string name)
+        {
+            if (!TryGet(name, out var bytes))
+            {
+                throw new KeyNotFoundException(name);
+            }
+            return MessagePackSerializer.Deserialize<T>(bytes);
+        }
+    }
+}
+`
+	if err := os.MkdirAll(outdir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outdir, "TableBundle.cs"), []byte(code), 0o666)
+}
@@ -0,0 +1,450 @@
+// 计算列
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// 计算列表达式
+// eg.: calc:int = price*3/10
+// 支持 + - * / % 、括号以及函数 min,max,floor,ceil,round,abs
+// 字段引用使用原始字段名(如 price, s1.a)，只能引用数值或布尔类型的字段
+type CalcExpr struct {
+	Src  string   // 表达式源码
+	Refs []*Field // 引用的字段列表
+	root calcNode
+}
+
+type calcNode interface {
+	eval(row []string) (float64, error)
+}
+
+type calcNum float64
+
+type calcRef struct {
+	field *Field
+}
+
+type calcUnary struct {
+	op byte
+	x  calcNode
+}
+
+type calcBinary struct {
+	op   byte
+	l, r calcNode
+}
+
+type calcCall struct {
+	name string
+	args []calcNode
+}
+
+var calcFuncs = map[string]int{
+	"min":   -1,
+	"max":   -1,
+	"floor": 1,
+	"ceil":  1,
+	"round": 1,
+	"abs":   1,
+}
+
+func (n calcNum) eval(row []string) (float64, error) {
+	return float64(n), nil
+}
+
+func (n *calcRef) eval(row []string) (float64, error) {
+	f := n.field
+	s := ""
+	if f.Index < len(row) {
+		s = strings.TrimSpace(row[f.Index])
+	}
+	if len(s) == 0 {
+		return 0, nil
+	}
+	if f.Kind == TBool {
+		return ternary(s == "1" || s == "true", 1.0, 0.0), nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("字段%s的值[%s]不是数值", f.Rname, s)
+	}
+	return v, nil
+}
+
+func (n *calcUnary) eval(row []string) (float64, error) {
+	v, err := n.x.eval(row)
+	if err != nil {
+		return 0, err
+	}
+	if n.op == '-' {
+		return -v, nil
+	}
+	return v, nil
+}
+
+func (n *calcBinary) eval(row []string) (float64, error) {
+	l, err := n.l.eval(row)
+	if err != nil {
+		return 0, err
+	}
+	r, err := n.r.eval(row)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case '+':
+		return l + r, nil
+	case '-':
+		return l - r, nil
+	case '*':
+		return l * r, nil
+	case '/':
+		if r == 0 {
+			return 0, errors.New("除数为0")
+		}
+		return l / r, nil
+	case '%':
+		if r == 0 {
+			return 0, errors.New("除数为0")
+		}
+		return math.Mod(l, r), nil
+	}
+	return 0, fmt.Errorf("未知运算符%c", n.op)
+}
+
+func (n *calcCall) eval(row []string) (float64, error) {
+	args := make([]float64, 0, len(n.args))
+	for _, a := range n.args {
+		v, err := a.eval(row)
+		if err != nil {
+			return 0, err
+		}
+		args = append(args, v)
+	}
+	switch n.name {
+	case "min":
+		v := args[0]
+		for _, a := range args[1:] {
+			v = math.Min(v, a)
+		}
+		return v, nil
+	case "max":
+		v := args[0]
+		for _, a := range args[1:] {
+			v = math.Max(v, a)
+		}
+		return v, nil
+	case "floor":
+		return math.Floor(args[0]), nil
+	case "ceil":
+		return math.Ceil(args[0]), nil
+	case "round":
+		return math.Round(args[0]), nil
+	case "abs":
+		return math.Abs(args[0]), nil
+	}
+	return 0, fmt.Errorf("未知函数%s", n.name)
+}
+
+//#region MARK: 表达式解析
+
+type calcParser struct {
+	src    string
+	pos    int
+	lookup func(name string) (*Field, error)
+	refs   []*Field
+}
+
+// parseCalcExpr 解析计算表达式，lookup 用于查找引用的字段
+func parseCalcExpr(src string, lookup func(name string) (*Field, error)) (*CalcExpr, error) {
+	p := &calcParser{src: src, lookup: lookup}
+	root, err := p.parseAdd()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("无法识别的字符[%s]", p.src[p.pos:])
+	}
+	return &CalcExpr{Src: src, Refs: p.refs, root: root}, nil
+}
+
+func (p *calcParser) skipSpace() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *calcParser) peek() byte {
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+func (p *calcParser) parseAdd() (calcNode, error) {
+	l, err := p.parseMul()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '+' && op != '-' {
+			return l, nil
+		}
+		p.pos++
+		r, err := p.parseMul()
+		if err != nil {
+			return nil, err
+		}
+		l = &calcBinary{op: op, l: l, r: r}
+	}
+}
+
+func (p *calcParser) parseMul() (calcNode, error) {
+	l, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek()
+		if op != '*' && op != '/' && op != '%' {
+			return l, nil
+		}
+		p.pos++
+		r, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		l = &calcBinary{op: op, l: l, r: r}
+	}
+}
+
+func (p *calcParser) parseUnary() (calcNode, error) {
+	op := p.peek()
+	if op == '-' || op == '+' {
+		p.pos++
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &calcUnary{op: op, x: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *calcParser) parsePrimary() (calcNode, error) {
+	c := p.peek()
+	switch {
+	case c == 0:
+		return nil, errors.New("表达式不完整")
+	case c == '(':
+		p.pos++
+		x, err := p.parseAdd()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, errors.New("缺少右括号")
+		}
+		p.pos++
+		return x, nil
+	case (c >= '0' && c <= '9') || c == '.':
+		start := p.pos
+		for p.pos < len(p.src) && ((p.src[p.pos] >= '0' && p.src[p.pos] <= '9') || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		v, err := strconv.ParseFloat(p.src[start:p.pos], 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的数字[%s]", p.src[start:p.pos])
+		}
+		return calcNum(v), nil
+	case isCalcIdentStart(c):
+		start := p.pos
+		for p.pos < len(p.src) && (isCalcIdentStart(p.src[p.pos]) || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9') || p.src[p.pos] == '.') {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		if p.peek() == '(' {
+			return p.parseCall(name)
+		}
+		f, err := p.lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		p.refs = append(p.refs, f)
+		return &calcRef{field: f}, nil
+	}
+	return nil, fmt.Errorf("无法识别的字符[%c]", c)
+}
+
+func (p *calcParser) parseCall(name string) (calcNode, error) {
+	argc, ok := calcFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("未知函数%s", name)
+	}
+	p.pos++ // (
+	args := make([]calcNode, 0, 2)
+	if p.peek() != ')' {
+		for {
+			x, err := p.parseAdd()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, x)
+			if p.peek() != ',' {
+				break
+			}
+			p.pos++
+		}
+	}
+	if p.peek() != ')' {
+		return nil, errors.New("缺少右括号")
+	}
+	p.pos++
+	if (argc > 0 && len(args) != argc) || len(args) == 0 {
+		return nil, fmt.Errorf("函数%s参数个数错误", name)
+	}
+	return &calcCall{name: name, args: args}, nil
+}
+
+func isCalcIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+//#endregion
+
+//#region MARK: 计算列求值
+
+// 收集计算列
+func (x *Xlsx) collectCalcFields(field *Field) {
+	if len(field.Expr) > 0 && field.Index >= 0 {
+		x.CalcFields = append(x.CalcFields, field)
+	}
+	for _, k := range field.Keys {
+		x.collectCalcFields(k)
+	}
+	for _, v := range field.Vals {
+		x.collectCalcFields(v)
+	}
+}
+
+// 查找可被计算列引用的字段
+func (x *Xlsx) findCalcRef(field *Field, name string) (*Field, error) {
+	var found *Field
+	var walk func(f *Field)
+	walk = func(f *Field) {
+		if found != nil {
+			return
+		}
+		if f.Index >= 0 && f.Rname == name {
+			found = f
+			return
+		}
+		for _, v := range f.Vals {
+			walk(v)
+		}
+	}
+	walk(x.RootField)
+
+	if found == nil {
+		return nil, fmt.Errorf("引用的字段%s不存在", name)
+	}
+	if found == field {
+		return nil, errors.New("不能引用自身")
+	}
+	if !(found.isNumber() || found.Kind == TBool) {
+		return nil, fmt.Errorf("引用的字段%s不是数值类型", name)
+	}
+	if len(found.Expr) > 0 && found.Index > field.Index {
+		return nil, fmt.Errorf("引用的计算列%s必须在当前列之前", name)
+	}
+	return found, nil
+}
+
+// 编译计算列表达式
+func (x *Xlsx) compileCalcFields() {
+	for _, f := range x.CalcFields {
+		calc, err := parseCalcExpr(f.Expr, func(name string) (*Field, error) {
+			return x.findCalcRef(f, name)
+		})
+		if err != nil {
+			x.sprintfCellError(TypeLine, f.Index+1, "计算列表达式错误: %v", err)
+			continue
+		}
+		f.Calc = calc
+	}
+}
+
+// 计算行内所有计算列的值，并回填到行数据中
+func (x *Xlsx) evalCalcFields(row []string, line int) ([]string, bool) {
+	ok := true
+	for _, f := range x.CalcFields {
+		if f.Calc == nil {
+			continue
+		}
+		v, err := f.Calc.root.eval(row)
+		if err != nil {
+			x.sprintfCellError(line, f.Index+1, "计算列求值错误: %v", err)
+			ok = false
+			continue
+		}
+
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			x.sprintfCellError(line, f.Index+1, "计算列求值错误: 结果%v不是有效的数值", v)
+			ok = false
+			continue
+		}
+
+		var val string
+		switch f.Kind {
+		case TInt, TUint:
+			if math.Abs(v-math.Round(v)) > 1e-9 {
+				x.sprintfCellError(line, f.Index+1, "计算列求值错误: 结果%v不是整数", v)
+				ok = false
+				continue
+			}
+			// 转换前检查范围(float64 转换为超出范围的整数时结果不确定)
+			v = math.Round(v)
+			if f.Kind == TUint {
+				if v < 0 || v >= math.MaxUint64 {
+					x.sprintfCellError(line, f.Index+1, "计算列求值错误: 结果%v超出无符号整数范围", v)
+					ok = false
+					continue
+				}
+				val = strconv.FormatUint(uint64(v), 10)
+			} else {
+				if v < math.MinInt64 || v >= math.MaxInt64 {
+					x.sprintfCellError(line, f.Index+1, "计算列求值错误: 结果%v超出整数范围", v)
+					ok = false
+					continue
+				}
+				val = strconv.FormatInt(int64(v), 10)
+			}
+		default:
+			val = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+
+		// 与普通列相同的类型检查和取值约束
+		if err := f.checkValue(val); err != nil {
+			x.sprintfCellError(line, f.Index+1, "计算列求值错误: %v", err)
+			ok = false
+			continue
+		}
+
+		for len(row) <= f.Index {
+			row = append(row, "")
+		}
+		row[f.Index] = val
+	}
+	return row, ok
+}
+
+//#endregion
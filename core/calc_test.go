@@ -0,0 +1,114 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// 由表头创建配置表(不读取文件)
+func newTestXlsx(t *testing.T, names, types []string) *Xlsx {
+	t.Helper()
+	x := &Xlsx{
+		Names: names,
+		Types: types,
+		Modes: make([]string, len(names)),
+		Descs: make([]string, len(names)),
+	}
+	x.parseHeader()
+	x.checkFields()
+	if len(x.Errors) > 0 {
+		t.Fatalf("表头错误: %v", x.Errors)
+	}
+	return x
+}
+
+func TestParseCalcExpr(t *testing.T) {
+	fields := map[string]*Field{
+		"a":   {Index: 0, Rname: "a", Type: parseType("int")},
+		"b":   {Index: 1, Rname: "b", Type: parseType("float")},
+		"s.c": {Index: 2, Rname: "s.c", Type: parseType("int")},
+		"d":   {Index: 3, Rname: "d", Type: parseType("bool")},
+	}
+	lookup := func(name string) (*Field, error) {
+		if f, ok := fields[name]; ok {
+			return f, nil
+		}
+		return nil, fmt.Errorf("引用的字段%s不存在", name)
+	}
+	row := []string{"7", "2.5", "-3", "true"}
+
+	tests := []struct {
+		src  string
+		want float64
+		err  string // 解析或求值错误
+	}{
+		{src: "1 + 2 * 3", want: 7},
+		{src: "(1 + 2) * 3", want: 9},
+		{src: "a % 4", want: 3},
+		{src: "-a + s.c", want: -10},
+		{src: "a * b", want: 17.5},
+		{src: "a + d", want: 8},
+		{src: "max(a, b, s.c)", want: 7},
+		{src: "min(a, b)", want: 2.5},
+		{src: "floor(b) + ceil(b) + round(b)", want: 8},
+		{src: "abs(s.c)", want: 3},
+		{src: "a / 0", err: "除数为0"},
+		{src: "a % (b - 2.5)", err: "除数为0"},
+		{src: "a +", err: "表达式不完整"},
+		{src: "(a + 1", err: "缺少右括号"},
+		{src: "x * 2", err: "引用的字段x不存在"},
+		{src: "floor(a, b)", err: "参数个数错误"},
+		{src: "pow(a, 2)", err: "未知函数pow"},
+		{src: "a b", err: "无法识别的字符"},
+	}
+	for _, tt := range tests {
+		calc, err := parseCalcExpr(tt.src, lookup)
+		var v float64
+		if err == nil {
+			v, err = calc.root.eval(row)
+		}
+		if len(tt.err) > 0 {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: error %v, want %q", tt.src, err, tt.err)
+			}
+		} else if err != nil || v != tt.want {
+			t.Errorf("%q = %v (%v), want %v", tt.src, v, err, tt.want)
+		}
+	}
+}
+
+func TestEvalCalcFields(t *testing.T) {
+	tests := []struct {
+		typ  string
+		a    string
+		want string
+		err  string
+	}{
+		{typ: "calc:int = a * 2", a: "21", want: "42"},
+		{typ: "calc:uint = a - 1", a: "1", want: "0"},
+		{typ: "calc:float = a / 4", a: "1", want: "0.25"},
+		{typ: "calc:int = a / 4", a: "1", err: "不是整数"},
+		{typ: "calc:uint = a - 2", a: "1", err: "超出无符号整数范围"},
+		{typ: "calc:int = a * a * a", a: "10000000", err: "超出整数范围"},
+		{typ: "calc:int = -a * a * a", a: "10000000", err: "超出整数范围"},
+		{typ: "calc:uint = a * a * a", a: "100000000", err: "超出无符号整数范围"},
+		{typ: "calc:float = a * a * a", a: "1e200", err: "不是有效的数值"},
+		{typ: "calc:int(1,10) = a * 2", a: "5", want: "10"},
+		{typ: "calc:int(1,10) = a * 2", a: "6", err: "超出范围(1,10)"},
+		{typ: "calc:uint(1|2) = a + 1", a: "2", err: "不是可选值(1|2)之一"},
+	}
+	for _, tt := range tests {
+		x := newTestXlsx(t, []string{"id", "a", "v"}, []string{"int", "float", tt.typ})
+		row, ok := x.evalCalcFields([]string{"1", tt.a, "stale"}, 5)
+		if len(tt.err) > 0 {
+			if ok || len(x.Errors) == 0 || !strings.Contains(x.Errors[0], tt.err) {
+				t.Errorf("%s (a=%s): errors %v, want %q", tt.typ, tt.a, x.Errors, tt.err)
+			}
+			continue
+		}
+		if !ok || row[2] != tt.want {
+			t.Errorf("%s (a=%s) = %q %v, want %q", tt.typ, tt.a, row[2], x.Errors, tt.want)
+		}
+	}
+}
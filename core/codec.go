@@ -0,0 +1,359 @@
+// 导出文件压缩、加密
+
+package core
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4/v4"
+)
+
+// 打包文件格式:
+// 魔数(4字节) | 压缩算法(1字节) | 标记(1字节) | 数据
+// 加密时数据为 nonce(12字节) | 密文 | GCM 校验(16字节)，文件头作为 GCM 附加数据
+const (
+	PackMagic     = "EPK1"
+	PackHeaderLen = 6
+)
+
+// 压缩算法
+const (
+	CompressNone byte = iota
+	CompressGzip
+	CompressZstd
+	CompressLz4
+)
+
+// 打包标记
+const (
+	PackEncrypted byte = 1 << iota // 已加密
+)
+
+var CompressNames = map[string]byte{
+	"gzip": CompressGzip,
+	"zstd": CompressZstd,
+	"lz4":  CompressLz4,
+}
+
+var (
+	packCompress byte   // 压缩算法
+	packKey      []byte // AES 密钥(16、24 或 32 字节)
+)
+
+// 是否需要打包导出文件
+func packEnabled() bool {
+	return packCompress != CompressNone || len(packKey) > 0
+}
+
+// 加载压缩、加密选项
+func LoadPackOptions() error {
+	packCompress = CompressNone
+	packKey = nil
+
+	if len(GFlags.Compress) > 0 {
+		c, ok := CompressNames[GFlags.Compress]
+		if !ok {
+			return fmt.Errorf("不支持的压缩算法[%s](支持 gzip、zstd、lz4)", GFlags.Compress)
+		}
+		packCompress = c
+	}
+	if len(GFlags.EncryptKey) > 0 {
+		key, err := loadEncryptKey(GFlags.EncryptKey)
+		if err != nil {
+			return err
+		}
+		packKey = key
+	}
+	return nil
+}
+
+// 读取密钥，env:变量名 表示从环境变量读取，否则为密钥文件路径
+// 密钥内容可以是 hex、base64 文本，或者原始的 16、24、32 字节
+func loadEncryptKey(spec string) ([]byte, error) {
+	var raw []byte
+	if name, ok := strings.CutPrefix(spec, "env:"); ok {
+		raw = []byte(os.Getenv(name))
+		if len(raw) == 0 {
+			return nil, fmt.Errorf("环境变量[%s]未设置", name)
+		}
+	} else {
+		data, err := os.ReadFile(spec)
+		if err != nil {
+			return nil, fmt.Errorf("密钥文件[%s]读取失败: %v", spec, err)
+		}
+		raw = data
+	}
+
+	isKeyLen := func(n int) bool { return n == 16 || n == 24 || n == 32 }
+	text := strings.TrimSpace(string(raw))
+	if key, err := hex.DecodeString(text); err == nil && isKeyLen(len(key)) {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && isKeyLen(len(key)) {
+		return key, nil
+	}
+	if isKeyLen(len(raw)) {
+		return raw, nil
+	}
+	return nil, errors.New("密钥长度必须为 16、24 或 32 字节(支持 hex、base64 或原始字节)")
+}
+
+// 压缩、加密导出数据(格式化之后，写入文件之前)
+func (x *Xlsx) packOutput(format string) {
+	if !packEnabled() {
+		return
+	}
+
+	switch format {
+	case "csharp":
+		data, err := packData(x.BinaryDatas)
+		if err != nil {
+			x.appendError(err.Error())
+			return
+		}
+		x.BinaryDatas = data
+	case "lua", "json", "yaml", "toml":
+		data, err := packData([]byte(strings.Join(x.Datas, "")))
+		if err != nil {
+			x.appendError(err.Error())
+			return
+		}
+		x.Datas = []string{string(data)}
+	}
+}
+
+func packData(data []byte) ([]byte, error) {
+	header := []byte(PackMagic)
+	header = append(header, packCompress, 0)
+
+	var payload []byte
+	var err error
+	switch packCompress {
+	case CompressGzip:
+		var b bytes.Buffer
+		w, _ := gzip.NewWriterLevel(&b, gzip.BestCompression)
+		w.Write(data)
+		err = w.Close()
+		payload = b.Bytes()
+	case CompressZstd:
+		var enc *zstd.Encoder
+		enc, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBestCompression))
+		if err == nil {
+			payload = enc.EncodeAll(data, nil)
+			enc.Close()
+		}
+	case CompressLz4:
+		var b bytes.Buffer
+		w := lz4.NewWriter(&b)
+		w.Write(data)
+		err = w.Close()
+		payload = b.Bytes()
+	default:
+		payload = data
+	}
+	if err != nil {
+		return nil, fmt.Errorf("压缩失败: %v", err)
+	}
+
+	if len(packKey) > 0 {
+		header[5] |= PackEncrypted
+		gcm, err := newPackGCM()
+		if err != nil {
+			return nil, err
+		}
+		nonce := make([]byte, gcm.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, fmt.Errorf("加密失败: %v", err)
+		}
+		payload = gcm.Seal(nonce, nonce, payload, header)
+	}
+	return append(header, payload...), nil
+}
+
+// 还原打包数据，未打包的数据原样返回
+func unpackData(data []byte) ([]byte, error) {
+	if len(data) < PackHeaderLen || string(data[:4]) != PackMagic {
+		return data, nil
+	}
+
+	header := data[:PackHeaderLen]
+	payload := data[PackHeaderLen:]
+	if header[5]&PackEncrypted != 0 {
+		if len(packKey) == 0 {
+			return nil, errors.New("数据已加密，缺少密钥")
+		}
+		gcm, err := newPackGCM()
+		if err != nil {
+			return nil, err
+		}
+		if len(payload) < gcm.NonceSize() {
+			return nil, errors.New("加密数据不完整")
+		}
+		payload, err = gcm.Open(nil, payload[:gcm.NonceSize()], payload[gcm.NonceSize():], header)
+		if err != nil {
+			return nil, fmt.Errorf("解密失败: %v", err)
+		}
+	}
+
+	switch header[4] {
+	case CompressGzip:
+		r, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	case CompressZstd:
+		dec, err := zstd.NewReader(nil)
+		if err != nil {
+			return nil, err
+		}
+		defer dec.Close()
+		return dec.DecodeAll(payload, nil)
+	case CompressLz4:
+		return io.ReadAll(lz4.NewReader(bytes.NewReader(payload)))
+	case CompressNone:
+		return payload, nil
+	}
+	return nil, fmt.Errorf("未知的压缩算法(%d)", header[4])
+}
+
+func newPackGCM() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(packKey)
+	if err != nil {
+		return nil, fmt.Errorf("加密失败: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+//#region MARK: 导出 TableCodec.cs 相关
+
+// WriteTableCodecCSharp 在 outdir 下生成解码打包数据的 TableCodec.cs
+// 只包含本次使用的压缩算法，避免引用未安装的压缩库
+func WriteTableCodecCSharp(outdir string) error {
+	usings := []string{"System", "System.IO"}
+	decompress := ""
+	switch packCompress {
+	case CompressGzip:
+		usings = append(usings, "System.IO.Compression")
+		decompress = `                case 1: // gzip
+                    using (var input = new GZipStream(new MemoryStream(payload), CompressionMode.Decompress))
+                    using (var output = new MemoryStream())
+                    {
+                        input.CopyTo(output);
+                        return output.ToArray();
+                    }
+`
+	case CompressZstd:
+		decompress = `                case 2: // zstd(ZstdSharp.Port)
+                    using (var decompressor = new ZstdSharp.Decompressor())
+                    {
+                        return decompressor.Unwrap(payload).ToArray();
+                    }
+`
+	case CompressLz4:
+		decompress = `                case 3: // lz4 frame(K4os.Compression.LZ4.Streams)
+                    using (var input = K4os.Compression.LZ4.Streams.LZ4Stream.Decode(new MemoryStream(payload)))
+                    using (var output = new MemoryStream())
+                    {
+                        input.CopyTo(output);
+                        return output.ToArray();
+                    }
+`
+	}
+	decrypt := ""
+	if len(packKey) > 0 {
+		usings = append(usings, "System.Security.Cryptography")
+		decrypt = `            if ((data[5] & 1) != 0)
+            {
+                if (Key == null)
+                {
+                    throw new InvalidOperationException("TableCodec.Key is not set");
+                }
+                var header = new ReadOnlySpan<byte>(data, 0, HeaderLength);
+                var nonce = new ReadOnlySpan<byte>(data, HeaderLength, NonceLength);
+                int cipherLength = data.Length - HeaderLength - NonceLength - TagLength;
+                var cipherText = new ReadOnlySpan<byte>(data, HeaderLength + NonceLength, cipherLength);
+                var tag = new ReadOnlySpan<byte>(data, data.Length - TagLength, TagLength);
+                payload = new byte[cipherLength];
+                using (var aes = new AesGcm(Key, TagLength))
+                {
+                    aes.Decrypt(nonce, cipherText, tag, payload, header);
+                }
+            }
+`
+	}
+
+	var sb strings.Builder
+	sb.WriteString("// Auto generated by excelparser. DO NOT EDIT!\n")
+	for _, u := range usings {
+		sb.WriteString("using " + u + ";\n")
+	}
+	sb.WriteString(`
+namespace Game.Table
+{
+    /// <summary>配置表打包数据(` + PackMagic + `)解码</summary>
+    public static class TableCodec
+    {
+        private const int HeaderLength = ` + fmt.Sprint(PackHeaderLen) + `;
+        private const int NonceLength = 12;
+        private const int TagLength = 16;
+
+        /// <summary>AES-GCM 密钥(16、24 或 32 字节)</summary>
+        public static byte[] Key;
+
+        /// <summary>解码打包数据，未打包的数据原样返回</summary>
+        public static byte[] Decode(byte[] data)
+        {
+            if (data.Length < HeaderLength || data[0] != '` + PackMagic[0:1] + `' || data[1] != '` + PackMagic[1:2] + `' || data[2] != '` + PackMagic[2:3] + `' || data[3] != '` + PackMagic[3:4] + `')
+            {
+                return data;
+            }
+
+            byte[] payload = new byte[data.Length - HeaderLength];
+            Buffer.BlockCopy(data, HeaderLength, payload, 0, payload.Length);
+`)
+	if len(decrypt) > 0 {
+		sb.WriteString(decrypt)
+	} else {
+		sb.WriteString(`            if ((data[5] & 1) != 0)
+            {
+                throw new NotSupportedException("encrypted table data");
+            }
+`)
+	}
+	sb.WriteString(`
+            switch (data[4])
+            {
+                case 0:
+                    return payload;
+`)
+	sb.WriteString(decompress)
+	sb.WriteString(`                default:
+                    throw new NotSupportedException("table compression " + data[4]);
+            }
+        }
+    }
+}
+`)
+
+	if err := os.MkdirAll(outdir, 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outdir, "TableCodec.cs"), []byte(sb.String()), 0o666)
+}
+
+//#endregion
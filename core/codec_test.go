@@ -0,0 +1,123 @@
+package core
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+// 设置打包选项，测试结束后恢复
+func setPackOptions(t *testing.T, compress byte, key []byte) {
+	t.Helper()
+	c, k := packCompress, packKey
+	t.Cleanup(func() { packCompress, packKey = c, k })
+	packCompress, packKey = compress, key
+}
+
+func TestPackDataRoundTrip(t *testing.T) {
+	data := []byte(strings.Repeat("return {id=1001,name=\"道具\"}\n", 100))
+	aesKey := bytes.Repeat([]byte{7}, 32)
+	for _, compress := range []byte{CompressNone, CompressGzip, CompressZstd, CompressLz4} {
+		for _, key := range [][]byte{nil, aesKey} {
+			setPackOptions(t, compress, key)
+			if !packEnabled() {
+				continue
+			}
+
+			packed, err := packData(data)
+			if err != nil {
+				t.Fatalf("compress %d key %d: %v", compress, len(key), err)
+			}
+			if string(packed[:4]) != PackMagic || packed[4] != compress || (packed[5]&PackEncrypted != 0) != (len(key) > 0) {
+				t.Errorf("compress %d key %d: 文件头错误 %v", compress, len(key), packed[:PackHeaderLen])
+			}
+			got, err := unpackData(packed)
+			if err != nil || !bytes.Equal(got, data) {
+				t.Errorf("compress %d key %d: 还原失败 %v", compress, len(key), err)
+			}
+		}
+	}
+}
+
+func TestPackDataNonce(t *testing.T) {
+	setPackOptions(t, CompressZstd, bytes.Repeat([]byte{1}, 16))
+	data := []byte("return {}")
+
+	// 相同数据每次打包使用不同的 nonce
+	nonces := make(map[string]bool)
+	for i := 0; i < 100; i++ {
+		packed, err := packData(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nonce := string(packed[PackHeaderLen : PackHeaderLen+12])
+		if nonces[nonce] {
+			t.Fatalf("第%d次打包 nonce 重复", i+1)
+		}
+		nonces[nonce] = true
+		if got, err := unpackData(packed); err != nil || !bytes.Equal(got, data) {
+			t.Fatalf("还原失败: %v", err)
+		}
+	}
+}
+
+func TestUnpackDataErrors(t *testing.T) {
+	setPackOptions(t, CompressGzip, bytes.Repeat([]byte{1}, 16))
+	packed, err := packData([]byte("return {}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 文件头作为附加数据，修改后校验失败
+	tampered := bytes.Clone(packed)
+	tampered[4] = CompressLz4
+	if _, err := unpackData(tampered); err == nil || !strings.Contains(err.Error(), "解密失败") {
+		t.Errorf("修改文件头: %v", err)
+	}
+	tampered = bytes.Clone(packed)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := unpackData(tampered); err == nil || !strings.Contains(err.Error(), "解密失败") {
+		t.Errorf("修改密文: %v", err)
+	}
+
+	packKey = bytes.Repeat([]byte{2}, 16)
+	if _, err := unpackData(packed); err == nil || !strings.Contains(err.Error(), "解密失败") {
+		t.Errorf("密钥错误: %v", err)
+	}
+	packKey = nil
+	if _, err := unpackData(packed); err == nil || !strings.Contains(err.Error(), "缺少密钥") {
+		t.Errorf("缺少密钥: %v", err)
+	}
+
+	// 未打包的数据原样返回
+	if got, err := unpackData([]byte("EPK")); err != nil || string(got) != "EPK" {
+		t.Errorf("未打包数据 = %q, %v", got, err)
+	}
+}
+
+func TestLoadEncryptKey(t *testing.T) {
+	key := bytes.Repeat([]byte{0xab}, 24)
+	tests := []struct {
+		val string
+		err string
+	}{
+		{val: hex.EncodeToString(key)},
+		{val: " " + base64.StdEncoding.EncodeToString(key) + "\n"},
+		{val: string(key)},
+		{val: "0123456789", err: "密钥长度"},
+		{val: "", err: "环境变量[EP_TEST_KEY]未设置"},
+	}
+	for _, tt := range tests {
+		t.Setenv("EP_TEST_KEY", tt.val)
+		got, err := loadEncryptKey("env:EP_TEST_KEY")
+		if len(tt.err) > 0 {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%q: error %v, want %q", tt.val, err, tt.err)
+			}
+		} else if err != nil || !bytes.Equal(got, key) {
+			t.Errorf("%q = %x, %v", tt.val, got, err)
+		}
+	}
+}
@@ -74,6 +74,9 @@ func (c *CSharpFormater) buildMsgpackData() any {
 		if strings.HasPrefix(key, "//") || key == "" {
 			continue
 		}
+		if !c.isRowHit(row, c.mode) {
+			continue
+		}
 		keyField := c.RootField.Vals[0]
 		keyVal := c.convertPrimitive(keyField.Type, key)
 		result[keyVal] = c.buildValue(c.RootField, row)
@@ -83,10 +86,14 @@ func (c *CSharpFormater) buildMsgpackData() any {
 
 // buildValue 递归构建字段值
 func (c *CSharpFormater) buildValue(field *Field, row []string) any {
+	if field.isInline() {
+		return c.buildInline(field.Type, field.inlineOf(row))
+	}
+
 	switch field.Kind {
 	case TArray:
 		arr := make([]any, 0, len(field.Vals))
-		for _, f := range field.Vals {
+		for _, f := range field.arrayVals(row) {
 			arr = append(arr, c.buildValue(f, row))
 		}
 		return arr
@@ -132,6 +139,32 @@ func (c *CSharpFormater) buildValue(field *Field, row []string) any {
 	}
 }
 
+// buildInline 构建单元格内联值
+func (c *CSharpFormater) buildInline(t *Type, v *inlineValue) any {
+	switch t.Kind {
+	case TArray:
+		arr := make([]any, 0, len(v.Vals))
+		for _, e := range v.Vals {
+			arr = append(arr, c.buildInline(t.Vtype, e))
+		}
+		return arr
+	case TMap:
+		m := make(map[any]any)
+		for i, k := range v.Keys {
+			m[c.convertPrimitive(t.Ktype, k.Val)] = c.buildInline(t.Vtype, v.Vals[i])
+		}
+		return m
+	case TStruct:
+		arr := make([]any, 0, len(t.Fnames))
+		for i, name := range t.Fnames {
+			arr = append(arr, c.buildInline(t.Ftypes[name], v.Vals[i]))
+		}
+		return arr
+	default:
+		return c.convertPrimitive(t, v.Val)
+	}
+}
+
 func (c *CSharpFormater) convertPrimitive(t *Type, val string) any {
 	val = strings.TrimSpace(val)
 	switch t.Kind {
@@ -204,6 +237,14 @@ func (c *CSharpFormater) convertJsonTypedValue(t *Type, val any) any {
 		}
 		return val
 	case TStruct:
+		if m, ok := val.(map[string]any); ok && t.isShared() {
+			// 共享类型与多列结构体一致，按字段顺序序列化为数组
+			result := make([]any, len(t.Fnames))
+			for i, fname := range t.Fnames {
+				result[i] = c.convertJsonTypedValue(t.Ftypes[fname], m[fname])
+			}
+			return result
+		}
 		if m, ok := val.(map[string]any); ok && t.Ftypes != nil {
 			result := make(map[string]any, len(m))
 			for k, v := range m {
@@ -271,6 +312,11 @@ func (c *CSharpFormater) collectNestedClasses(field *Field, parentClsName string
 }
 
 func (c *CSharpFormater) collectTypeNestedClasses(t *Type, parentClsName, fieldName string, field *Field, out *[]string, seen map[string]bool) {
+	if field.isInline() {
+		c.collectInlineTypeClasses(t, parentClsName, fieldName, out, seen)
+		return
+	}
+
 	switch t.Kind {
 	case TArray:
 		if t.Vtype != nil && len(field.Vals) > 0 {
@@ -281,7 +327,11 @@ func (c *CSharpFormater) collectTypeNestedClasses(t *Type, parentClsName, fieldN
 			c.collectTypeNestedClasses(t.Vtype, parentClsName, fieldName, field.Vals[0], out, seen)
 		}
 	case TStruct:
-		clsName := c.structClassName(t, parentClsName, fieldName)
+		if t.isShared() {
+			// 共享类型统一生成到 SharedTypes.cs
+			return
+		}
+		clsName := structClassName(t, parentClsName, fieldName)
 		if seen[clsName] {
 			return
 		}
@@ -319,6 +369,54 @@ func (c *CSharpFormater) collectTypeNestedClasses(t *Type, parentClsName, fieldN
 	}
 }
 
+// collectInlineTypeClasses 从 Type 树（Fnames）收集单元格内联结构体的 C# 类定义
+// 单元格结构体与多列结构体一样按字段顺序序列化为数组，因此使用 [Key(index)] 整数键
+func (c *CSharpFormater) collectInlineTypeClasses(t *Type, parentClsName, fieldName string, out *[]string, seen map[string]bool) {
+	switch t.Kind {
+	case TArray, TMap:
+		if t.Vtype != nil {
+			c.collectInlineTypeClasses(t.Vtype, parentClsName, fieldName, out, seen)
+		}
+	case TStruct:
+		if t.isShared() {
+			return
+		}
+		c.collectStructClass(t, structClassName(t, parentClsName, fieldName), "", nil, out, seen)
+	}
+}
+
+// collectStructClass 按 Fnames 顺序生成结构体类定义，并递归收集字段中的结构体类
+func (c *CSharpFormater) collectStructClass(t *Type, clsName, desc string, fdescs map[string]string, out *[]string, seen map[string]bool) {
+	if seen[clsName] {
+		return
+	}
+	seen[clsName] = true
+
+	var sb strings.Builder
+	if len(desc) > 0 {
+		sb.WriteString(fmt.Sprintf("    /// <summary>%s</summary>\n", desc))
+	}
+	sb.WriteString("    [MessagePackObject]\n")
+	sb.WriteString(fmt.Sprintf("    public class %s\n", clsName))
+	sb.WriteString("    {\n")
+
+	for i, fname := range t.Fnames {
+		ftype := t.Ftypes[fname]
+		if len(fdescs[fname]) > 0 {
+			sb.WriteString(fmt.Sprintf("        /// <summary>%s</summary>\n", fdescs[fname]))
+		}
+		sb.WriteString(fmt.Sprintf("        [Key(%d)]\n", i))
+		typeName := c.csharpTypeName(ftype, clsName, fname)
+		sb.WriteString(fmt.Sprintf("        public %s %s { get; set; }\n\n", typeName, toTitle(fname)))
+	}
+	sb.WriteString("    }\n")
+	*out = append(*out, sb.String())
+
+	for _, fname := range t.Fnames {
+		c.collectInlineTypeClasses(t.Ftypes[fname], clsName, fname, out, seen)
+	}
+}
+
 // collectJsonTypeClasses 从 Type 树（Ftypes）收集 JSON 内嵌结构体的 C# 类定义
 // JSON 数据经 json.Unmarshal 后为 map[string]any，msgpack 序列化为字符串键 map，
 // 因此生成的 C# 类使用 [Key("fieldName")] 字符串键
@@ -333,7 +431,10 @@ func (c *CSharpFormater) collectJsonTypeClasses(t *Type, parentClsName, fieldNam
 			c.collectJsonTypeClasses(t.Vtype, parentClsName, fieldName, out, seen)
 		}
 	case TStruct:
-		clsName := c.structClassName(t, parentClsName, fieldName)
+		if t.isShared() {
+			return
+		}
+		clsName := structClassName(t, parentClsName, fieldName)
 		if seen[clsName] {
 			return
 		}
@@ -366,13 +467,6 @@ func (c *CSharpFormater) collectJsonTypeClasses(t *Type, parentClsName, fieldNam
 	}
 }
 
-func (c *CSharpFormater) structClassName(t *Type, parentClsName, fieldName string) string {
-	if len(t.Aname) > 0 {
-		return toTitle(t.Aname)
-	}
-	return parentClsName + toTitle(fieldName)
-}
-
 // csharpTypeName 将内部 Type 映射为 C# 类型名
 func (c *CSharpFormater) csharpTypeName(t *Type, parentClsName, fieldName string) string {
 	switch t.Kind {
@@ -403,7 +497,7 @@ func (c *CSharpFormater) csharpTypeName(t *Type, parentClsName, fieldName string
 		}
 		return fmt.Sprintf("Dictionary<%s, %s>", kType, vType)
 	case TStruct:
-		return c.structClassName(t, parentClsName, fieldName)
+		return structClassName(t, parentClsName, fieldName)
 	case TJson:
 		if t.Vtype != nil {
 			return c.csharpTypeName(t.Vtype, parentClsName, fieldName)
@@ -416,6 +510,39 @@ func (c *CSharpFormater) csharpTypeName(t *Type, parentClsName, fieldName string
 	}
 }
 
+//#region MARK: 导出 SharedTypes.cs 相关
+
+// WriteSharedTypesCSharp 在 outdir 下生成共享类型的 C# 类定义
+func WriteSharedTypesCSharp(outdir string) {
+	c := &CSharpFormater{}
+	seen := make(map[string]bool)
+	classes := make([]string, 0, len(SharedTypeNames))
+	for _, name := range SharedTypeNames {
+		st := SharedTypes[name]
+		c.collectStructClass(st.Type, toTitle(st.Name), st.Desc, st.Fdescs, &classes, seen)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("// Auto generated by excelparser. DO NOT EDIT!\n")
+	sb.WriteString("using System.Collections.Generic;\n")
+	sb.WriteString("using MessagePack;\n\n")
+	sb.WriteString("namespace Game.Table\n{\n")
+	for i, cls := range classes {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(cls)
+	}
+	sb.WriteString("}\n")
+
+	if err := os.MkdirAll(outdir, 0o755); err != nil {
+		return
+	}
+	os.WriteFile(filepath.Join(outdir, "SharedTypes.cs"), []byte(sb.String()), 0o666)
+}
+
+//#endregion
+
 //#region MARK: 导出 GameTableProxy.cs 相关
 
 type proxyEntry struct {
@@ -6,7 +6,6 @@ import (
 	"sync"
 
 	"github.com/ibitcat/gotext"
-	"github.com/xuri/excelize/v2"
 )
 
 //#region constants
@@ -26,12 +25,12 @@ const (
 	TJson              // json
 )
 
-// 配置表头行定义
-const (
-	NameLine int = iota + 1
-	TypeLine
-	ModeLine
-	DescLine
+// 配置表头行定义(行号，可由项目配置调整顺序)
+var (
+	NameLine = 1 // 字段名行
+	TypeLine = 2 // 字段类型行
+	ModeLine = 3 // 导出模式行
+	DescLine = 4 // 字段描述行
 )
 
 //#endregion
@@ -40,16 +39,34 @@ const (
 
 // 导出选项
 type Flags struct {
-	Pretty   bool     // json格式化
-	Force    bool     // 是否强制重新生成
-	Compact  bool     // 是否紧凑导出
-	Path     string   // excel路径
-	Output   string   // 导出路径
-	Server   []string // server 导出格式（支持多个，逗号分隔）
-	Client   []string // client 导出格式（支持多个，逗号分隔）
-	I18nPath string   // 国际化配置路径
-	I18nLang string   // 国际化语言
-	Files    []string // 指定导出的文件列表（空=导出全部）
+	Pretty      bool              // json格式化
+	Force       bool              // 是否强制重新生成
+	Compact     bool              // 是否紧凑导出
+	Formula     bool              // 是否重新计算公式单元格
+	ErrorReport bool              // 导出失败时输出错误报告工作簿
+	LuaOpt      bool              // lua 优化导出(重复子表共享、默认值字段由元表提供)
+	LuaCols     []string          // 使用列布局导出的 lua 配置表(导出名，* 表示全部)
+	Path        string            // excel路径
+	Output      string            // 导出路径
+	Outputs     map[string]string // 内置目标(server、client)的输出目录(项目配置)
+	Server      []string          // server 导出格式（支持多个，逗号分隔）
+	Client      []string          // client 导出格式（支持多个，逗号分隔）
+	Targets     []Target          // 自定义导出目标
+	I18nPath    string            // 国际化配置路径
+	I18nLang    string            // 国际化语言
+	Files       []string          // 指定导出的文件列表（空=导出全部）
+	Tags        []string          // 导出带有指定标签的配置行(__tag 列)
+	Types       string            // 共享类型定义文件(yaml 或 xlsx)
+	Bundle      string            // 合并导出文件名(每个导出目标的每种格式合并为一个文件)
+	Compress    string            // 导出文件压缩算法(gzip、zstd、lz4)
+	EncryptKey  string            // 导出文件加密密钥(密钥文件路径或 env:环境变量名)
+}
+
+// 导出目标
+type Target struct {
+	Name    string   // 目标名(server,client 为内置目标)
+	Formats []string // 导出格式
+	Output  string   // 输出目录(空=output/目标名)
 }
 
 // 结构体定义
@@ -57,6 +74,7 @@ type ExportInfo struct {
 	Mode     string `json:"mode"`
 	Format   string `json:"format"`
 	LastTime uint64 `json:"lasttime"`
+	Settings string `json:"settings,omitempty"` // 导出设置摘要(设置变化后需要重新导出)
 }
 
 // 类型定义
@@ -68,21 +86,44 @@ type Type struct {
 	Ktype  *Type            // 键类型(for map)
 	Vtype  *Type            // 值类型(for map,array,json)
 	Ftypes map[string]*Type // 字段类型(for 匿名结构体)
+	Fnames []string         // 字段顺序(for 匿名结构体)
+	Expr   string           // 计算表达式(for 计算列)
+	Limit  *TypeLimit       // 取值约束(for int,uint,float,string)
+}
+
+// 基础类型的取值约束
+// eg.: int(1,100) 取值范围，int(1|2|3)、string(a|b|c) 枚举
+type TypeLimit struct {
+	Text string   // 约束原文(括号中的内容)
+	Min  *float64 // 最小值(包含，nil 表示不限)
+	Max  *float64 // 最大值(包含，nil 表示不限)
+	Enum []string // 枚举值
+}
+
+// 共享类型定义(类型注册表)
+type SharedType struct {
+	*Type                    // 结构体类型
+	Name   string            // 类型名
+	Desc   string            // 类型描述
+	Fdescs map[string]string // 字段描述
+	Source string            // 定义来源(文件名)
 }
 
 // 字段定义
 type Field struct {
-	*Type            // 字段数据类型
-	Parent  *Field   // 父字段
-	Xlsx    *Xlsx    // 所属excel
-	Index   int      // 字段索引
-	Desc    string   // 字段描述
-	Comment string   // 字段批注
-	Rname   string   // 原始字段名
-	Name    string   // 字段名
-	Mode    string   // 生成方式(s=server,c=client,x=none)
-	Keys    []*Field // 键元素列表
-	Vals    []*Field // 值元素列表
+	*Type             // 字段数据类型
+	Parent  *Field    // 父字段
+	Xlsx    *Xlsx     // 所属excel
+	Index   int       // 字段索引
+	Desc    string    // 字段描述
+	Comment string    // 字段批注
+	Rname   string    // 原始字段名
+	Name    string    // 字段名
+	Mode    string    // 生成方式(s=server,c=client,x=none,或逗号分隔的目标列表)
+	Targets []string  // 导出目标列表(由 Mode 解析)
+	Keys    []*Field  // 键元素列表
+	Vals    []*Field  // 值元素列表
+	Calc    *CalcExpr // 计算列表达式
 }
 
 // Excel配置表结构体
@@ -95,18 +136,27 @@ type Xlsx struct {
 	OutName      string         // 输出文件名(道具@item.xlsx, 输出为 item)
 	SheetName    string         // 工作表名
 	Vertical     bool           // 纵向表
-	Excel        *excelize.File // 打开的excel文件句柄
+	Compact      bool           // 紧凑导出(全局设置或单表设置)
+	Pretty       bool           // json格式化(全局设置或单表设置)
+	Sheet        SheetReader    // 打开的配置数据工作表
 	Names        []string       // 字段名列表
 	Types        []string       // 类型列表
 	Modes        []string       // 导出模式列表
 	Descs        []string       // 字段描述列表
 	Comments     map[int]string // 字段批注列表
 	RootField    *Field         // 根字段
+	CalcFields   []*Field       // 计算列列表(按列顺序)
+	ModeField    *Field         // 行导出目标字段(__mode 列)
+	TagField     *Field         // 行标签字段(__tag 列)
 	Rows         [][]string     // 合法的配置行
+	BadRows      []string       // 配置值错误(未通过检查)的行 id，纵向表为空字符串
 	Datas        []string       // 导出数据缓存
 	BinaryDatas  []byte         // 二进制导出数据缓存
+	SqlTable     *sqliteTable   // sqlite 导出数据缓存
 	Errors       []string       // 错误信息
+	CellErrors   []CellComment  // 单元格错误信息(不受错误数量限制)
 	Skipped      bool           // 是否跳过（文件无变化）
+	ErrorReport  string         // 错误报告工作簿路径(--error-report)
 	Exports      []ExportInfo   // 导出信息
 	LastModified uint64         // 最后修改时间
 	TimeCost     int            // 耗时
@@ -133,24 +183,56 @@ type CSharpFormater struct {
 	mode string
 }
 
+// YAML格式化器
+type YamlFormater struct {
+	*Xlsx
+	line int
+	mode string
+}
+
+// TOML格式化器
+type TomlFormater struct {
+	*Xlsx
+	line int
+	mode string
+}
+
+// SQLite格式化器
+type SqliteFormater struct {
+	*Xlsx
+	line int
+	mode string
+}
+
+// 保留列名
+const (
+	RowModeName = "__mode" // 行导出目标列
+	RowTagName  = "__tag"  // 行标签列
+)
+
 //#endregion
 
 //#region variables
 
 var (
-	GFlags      Flags
-	HeadLineNum = 4                                                // 配置表头行数
-	IndentStr   map[int]string                                     // 缩进字符串映射
-	ArrayRe     = regexp.MustCompile(`^\[(\d*?)\](.+)`)            // 数组类型正则表达式
-	MapRe       = regexp.MustCompile(`^map\[(.+?)\](.+)`)          // map类型正则表达式
-	BasicTypes  = []string{"int", "uint", "bool", "string", "var"} // 基本类型列表
-	I18nMap     sync.Map                                           // 国际化字符串映射
-	I18nLocale  *gotext.Locale                                     // 国际化对象
-	XlsxList    []*Xlsx                                            // Excel配置表列表
-	EventChan   chan *ParseEvent                                   // 解析事件通道
-	MaxErrorCnt = 6                                                // 每个文件最大错误数
-	ExportYaml  = ".excelparser.cache"                             // 导出记录文件名
-	ExportCost  = 0                                                // 总耗时
+	GFlags       Flags
+	HeadLineNum  = 4                                                // 配置表头行数
+	IndentStr    map[int]string                                     // 缩进字符串映射
+	ArrayRe      = regexp.MustCompile(`^\[(\d*?)\](.+)`)            // 数组类型正则表达式
+	MapRe        = regexp.MustCompile(`^map\[(.+?)\](.+)`)          // map类型正则表达式
+	ExtRefRe     = regexp.MustCompile(`\[[^\]]+\][^!\s(),]*!`)      // 公式外部工作簿引用正则表达式
+	TargetNameRe = regexp.MustCompile(`^[\p{L}\p{N}_-]+$`)          // 自定义导出目标名正则表达式
+	BasicTypes   = []string{"int", "uint", "bool", "string", "var"} // 基本类型列表
+	I18nMap      sync.Map                                           // 国际化字符串映射
+	I18nLocale   *gotext.Locale                                     // 国际化对象
+	XlsxList     []*Xlsx                                            // Excel配置表列表
+	SharedTypes  map[string]*SharedType                             // 共享类型注册表
+	TypesXlsx    []string                                           // 共享类型定义工作簿列表
+	EventChan    chan *ParseEvent                                   // 解析事件通道
+	MaxErrorCnt  = 6                                                // 每个文件最大错误数
+	ExportYaml   = ".excelparser.cache"                             // 导出记录文件名
+	ExportCost   = 0                                                // 总耗时
+	TableConfigs map[string]*TableConfig                            // 单表设置(项目配置)
 )
 
 //#endregion
@@ -0,0 +1,377 @@
+// 配置表比较
+
+package core
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// 配置表比较结果
+type TableDiff struct {
+	Name    string         `json:"name"`              // 配置表文件名
+	Old     string         `json:"old"`               // 旧版本文件
+	New     string         `json:"new"`               // 新版本文件
+	Header  []HeaderChange `json:"header,omitempty"`  // 表头变化
+	Added   []string       `json:"added,omitempty"`   // 新增的 id
+	Removed []string       `json:"removed,omitempty"` // 删除的 id
+	Invalid []string       `json:"invalid,omitempty"` // 配置值错误而无法比较的行 id(新旧版本)
+	Changed []CellChange   `json:"changed,omitempty"` // 修改的值
+	Errors  []string       `json:"errors,omitempty"`  // 解析错误(新旧版本)
+}
+
+// 表头变化
+type HeaderChange struct {
+	Field string `json:"field"`         // 字段路径
+	Kind  string `json:"kind"`          // added, removed, type, mode, desc, comment, layout
+	Old   string `json:"old,omitempty"` // 旧值
+	New   string `json:"new,omitempty"` // 新值
+}
+
+// 值变化，值为 json 文本，不存在时为空
+type CellChange struct {
+	Id   string `json:"id"`            // 行 id(纵向表为空)
+	Path string `json:"path"`          // 字段路径(eg.: reward[0].num)
+	Old  string `json:"old,omitempty"` // 旧值
+	New  string `json:"new,omitempty"` // 新值
+}
+
+// 表头列
+type headerColumn struct {
+	Index   int
+	Path    string
+	Type    string
+	Mode    string
+	Desc    string
+	Comment string
+}
+
+// 是否没有变化
+func (d *TableDiff) IsEmpty() bool {
+	return len(d.Header) == 0 && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Invalid) == 0 && len(d.Changed) == 0
+}
+
+// DiffXlsx 比较同一个配置表的两个版本
+func DiffXlsx(old, cur *Xlsx) *TableDiff {
+	d := &TableDiff{Name: cur.Name, Old: old.PathName, New: cur.PathName}
+	for _, e := range old.Errors {
+		d.Errors = append(d.Errors, "旧版本: "+e)
+	}
+	for _, e := range cur.Errors {
+		d.Errors = append(d.Errors, "新版本: "+e)
+	}
+
+	if old.Vertical != cur.Vertical {
+		layout := func(v bool) string { return ternary(v, "纵向表", "横向表") }
+		d.Header = append(d.Header, HeaderChange{Kind: "layout", Old: layout(old.Vertical), New: layout(cur.Vertical)})
+	}
+	d.diffHeader(old.headerColumns(), cur.headerColumns())
+
+	// 未通过检查的行不在 Rows 中，不当作新增或删除
+	for _, id := range slices.Concat(old.BadRows, cur.BadRows) {
+		if !slices.Contains(d.Invalid, id) {
+			d.Invalid = append(d.Invalid, id)
+		}
+	}
+	oldRows, oldIds := old.rowNodes()
+	curRows, curIds := cur.rowNodes()
+	for _, id := range oldIds {
+		if _, ok := curRows[id]; !ok && !slices.Contains(cur.BadRows, id) {
+			d.Removed = append(d.Removed, id)
+		}
+	}
+	for _, id := range curIds {
+		o, ok := oldRows[id]
+		if !ok {
+			if !slices.Contains(old.BadRows, id) {
+				d.Added = append(d.Added, id)
+			}
+			continue
+		}
+		d.diffNode(id, "", o, curRows[id])
+	}
+	return d
+}
+
+func (d *TableDiff) diffHeader(olds, curs []*headerColumn) {
+	oldMap := make(map[string]*headerColumn, len(olds))
+	for _, c := range olds {
+		oldMap[c.Path] = c
+	}
+	curMap := make(map[string]*headerColumn, len(curs))
+	for _, c := range curs {
+		curMap[c.Path] = c
+	}
+
+	for _, c := range olds {
+		if _, ok := curMap[c.Path]; !ok {
+			d.Header = append(d.Header, HeaderChange{Field: c.Path, Kind: "removed", Old: c.Type})
+		}
+	}
+	for _, c := range curs {
+		o, ok := oldMap[c.Path]
+		if !ok {
+			d.Header = append(d.Header, HeaderChange{Field: c.Path, Kind: "added", New: c.Type})
+			continue
+		}
+		for _, v := range [][3]string{
+			{"type", o.Type, c.Type},
+			{"mode", o.Mode, c.Mode},
+			{"desc", o.Desc, c.Desc},
+			{"comment", o.Comment, c.Comment},
+		} {
+			if v[1] != v[2] {
+				d.Header = append(d.Header, HeaderChange{Field: c.Path, Kind: v[0], Old: v[1], New: v[2]})
+			}
+		}
+	}
+}
+
+func (d *TableDiff) diffNode(id, path string, a, b *valueNode) {
+	if a == nil || b == nil {
+		c := CellChange{Id: id, Path: path}
+		if a != nil {
+			c.Old = a.toJson()
+		}
+		if b != nil {
+			c.New = b.toJson()
+		}
+		d.Changed = append(d.Changed, c)
+		return
+	}
+
+	switch {
+	case a.Kind == NodeMap && b.Kind == NodeMap:
+		bIndex := make(map[string]int, len(b.Keys))
+		for i, k := range b.Keys {
+			bIndex[k] = i
+		}
+		aKeys := make(map[string]bool, len(a.Keys))
+		for i, k := range a.Keys {
+			aKeys[k] = true
+			var bv *valueNode
+			if j, ok := bIndex[k]; ok {
+				bv = b.Vals[j]
+			}
+			d.diffNode(id, joinNodePath(path, k, a.IntKeys), a.Vals[i], bv)
+		}
+		for j, k := range b.Keys {
+			if !aKeys[k] {
+				d.diffNode(id, joinNodePath(path, k, b.IntKeys), nil, b.Vals[j])
+			}
+		}
+	case a.Kind == NodeList && b.Kind == NodeList:
+		for i := range max(len(a.Vals), len(b.Vals)) {
+			var av, bv *valueNode
+			if i < len(a.Vals) {
+				av = a.Vals[i]
+			}
+			if i < len(b.Vals) {
+				bv = b.Vals[i]
+			}
+			d.diffNode(id, fmt.Sprintf("%s[%d]", path, i), av, bv)
+		}
+	default:
+		if oldVal, newVal := a.toJson(), b.toJson(); oldVal != newVal {
+			d.Changed = append(d.Changed, CellChange{Id: id, Path: path, Old: oldVal, New: newVal})
+		}
+	}
+}
+
+// 字段路径，整数键和非标识符键使用 [] 形式
+func joinNodePath(path, key string, intKey bool) string {
+	if intKey {
+		return fmt.Sprintf("%s[%s]", path, key)
+	}
+	if !TypeNameRe.MatchString(key) {
+		return fmt.Sprintf("%s[%q]", path, key)
+	}
+	if len(path) == 0 {
+		return key
+	}
+	return path + "." + key
+}
+
+// 表头列列表，数组元素为 path[i]，map 键值对为 path[i].key 和 path[i].value
+func (x *Xlsx) headerColumns() []*headerColumn {
+	cols := make([]*headerColumn, 0, len(x.Types))
+	cell := func(s []string, i int) string {
+		if i >= 0 && i < len(s) {
+			return strings.TrimSpace(s[i])
+		}
+		return ""
+	}
+	var walk func(f *Field, path string)
+	walk = func(f *Field, path string) {
+		if f.Index >= 0 {
+			cols = append(cols, &headerColumn{
+				Index:   f.Index,
+				Path:    path,
+				Type:    cell(x.Types, f.Index),
+				Mode:    cell(x.Modes, f.Index),
+				Desc:    cell(x.Descs, f.Index),
+				Comment: x.Comments[f.Index],
+			})
+		}
+		switch f.Kind {
+		case TArray:
+			for i, v := range f.Vals {
+				walk(v, fmt.Sprintf("%s[%d]", path, i))
+			}
+		case TMap:
+			for i, k := range f.Keys {
+				walk(k, fmt.Sprintf("%s[%d].key", path, i))
+				walk(f.Vals[i], fmt.Sprintf("%s[%d].value", path, i))
+			}
+		default:
+			for _, v := range f.Vals {
+				name := v.Name
+				if len(name) == 0 {
+					name = "#" + formatAxisX(v.Index+1)
+				}
+				walk(v, joinNodePath(path, name, false))
+			}
+		}
+	}
+	walk(x.RootField, "")
+	return cols
+}
+
+// 所有配置行的值(包括所有字段和保留列)，纵向表只有一行，id 为空
+func (x *Xlsx) rowNodes() (map[string]*valueNode, []string) {
+	b := &treeBuilder{Xlsx: x, all: true}
+	rows := make(map[string]*valueNode, len(x.Rows))
+	ids := make([]string, 0, len(x.Rows))
+	for _, row := range x.Rows {
+		b.line++
+		id := ""
+		if !x.Vertical {
+			id = strings.TrimSpace(row[0])
+		}
+		if _, ok := rows[id]; ok {
+			continue
+		}
+		rows[id] = b.buildField(x.RootField, row)
+		ids = append(ids, id)
+	}
+	return rows, ids
+}
+
+// 比较结果文本
+func (d *TableDiff) Text() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("--- %s\n+++ %s\n", d.Old, d.New))
+	if d.IsEmpty() {
+		sb.WriteString("无变化\n")
+	}
+	if len(d.Header) > 0 {
+		sb.WriteString("表头:\n")
+		kinds := map[string]string{"type": "类型", "mode": "导出模式", "desc": "描述", "comment": "批注"}
+		for _, h := range d.Header {
+			switch h.Kind {
+			case "layout":
+				sb.WriteString(fmt.Sprintf("  ~ %s → %s\n", h.Old, h.New))
+			case "added":
+				sb.WriteString(fmt.Sprintf("  + %s: %s\n", h.Field, h.New))
+			case "removed":
+				sb.WriteString(fmt.Sprintf("  - %s: %s\n", h.Field, h.Old))
+			default:
+				sb.WriteString(fmt.Sprintf("  ~ %s %s: %q → %q\n", h.Field, kinds[h.Kind], h.Old, h.New))
+			}
+		}
+	}
+	if len(d.Added) > 0 {
+		sb.WriteString(fmt.Sprintf("新增: %s\n", strings.Join(d.Added, ", ")))
+	}
+	if len(d.Removed) > 0 {
+		sb.WriteString(fmt.Sprintf("删除: %s\n", strings.Join(d.Removed, ", ")))
+	}
+	if len(d.Invalid) > 0 {
+		ids := make([]string, 0, len(d.Invalid))
+		for _, id := range d.Invalid {
+			ids = append(ids, ternary(len(id) > 0, id, "(纵向表)"))
+		}
+		sb.WriteString(fmt.Sprintf("配置值错误(无法比较，见解析错误): %s\n", strings.Join(ids, ", ")))
+	}
+	if len(d.Changed) > 0 {
+		sb.WriteString("修改:\n")
+		for _, c := range d.Changed {
+			sb.WriteString("  ")
+			if len(c.Id) > 0 {
+				sb.WriteString("[" + c.Id + "] ")
+			}
+			sb.WriteString(fmt.Sprintf("%s: %s → %s\n", c.Path, ternary(len(c.Old) > 0, c.Old, "(无)"), ternary(len(c.New) > 0, c.New, "(无)")))
+		}
+	}
+	if len(d.Errors) > 0 {
+		sb.WriteString("解析错误:\n")
+		for _, e := range d.Errors {
+			sb.WriteString("  " + e + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// TextConv 配置表的文本形式(git textconv 使用)
+// 每个表头列一行，每个配置行一行(json)，便于 git diff 按行比较
+func TextConv(x *Xlsx) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# %s (%s)\n", x.Name, x.SheetName))
+	for _, c := range x.headerColumns() {
+		sb.WriteString(fmt.Sprintf("# %s: %s", c.Path, c.Type))
+		if len(c.Mode) > 0 {
+			sb.WriteString(fmt.Sprintf(" [%s]", c.Mode))
+		}
+		if len(c.Desc) > 0 {
+			sb.WriteString(" " + c.Desc)
+		}
+		if len(c.Comment) > 0 {
+			sb.WriteString(" // " + c.Comment)
+		}
+		sb.WriteString("\n")
+	}
+	rows, ids := x.rowNodes()
+	for _, id := range ids {
+		if len(id) > 0 {
+			sb.WriteString(id + ": ")
+		}
+		sb.WriteString(rows[id].toJson() + "\n")
+	}
+	for _, e := range x.Errors {
+		sb.WriteString("# 错误: " + e + "\n")
+	}
+	return sb.String()
+}
+
+// GitShowFile 将文件在 git 版本 rev 中的内容提取到临时目录(文件名不变)
+// 返回临时文件路径和清理函数
+func GitShowFile(file, rev string) (string, func(), error) {
+	dir, base := filepath.Split(file)
+	cmd := exec.Command("git", "show", rev+":./"+base)
+	if len(dir) > 0 {
+		cmd.Dir = dir
+	}
+	data, err := cmd.Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok && len(ee.Stderr) > 0 {
+			return "", nil, fmt.Errorf("git show %s:%s 失败: %s", rev, base, strings.TrimSpace(string(ee.Stderr)))
+		}
+		return "", nil, fmt.Errorf("git show %s:%s 失败: %v", rev, base, err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "excelparser-")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(tmpDir) }
+	tmpFile := filepath.Join(tmpDir, base)
+	if err := os.WriteFile(tmpFile, data, 0o666); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return tmpFile, cleanup, nil
+}
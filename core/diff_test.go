@@ -0,0 +1,106 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// 创建只有一个 data 页签的工作簿，rows 包括 4 行表头
+func writeTestWorkbook(t *testing.T, path string, rows [][]string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", "data"); err != nil {
+		t.Fatal(err)
+	}
+	for i, row := range rows {
+		cells := make([]any, len(row))
+		for j, v := range row {
+			cells[j] = v
+		}
+		axis, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow("data", axis, &cells); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := f.SaveAs(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDiffXlsx(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old", "D-道具@item.xlsx")
+	curPath := filepath.Join(dir, "new", "D-道具@item.xlsx")
+	writeTestWorkbook(t, oldPath, [][]string{
+		{"id", "name", "num", "items"},
+		{"int", "string", "int", "[]int"},
+		{"", "", "", ""},
+		{"道具 id", "名称", "数量", "物品"},
+		{"1001", "a", "1", "1|2"},
+		{"1002", "b", "2", ""},
+		{"1003", "c", "3", ""},
+		{"1005", "e", "5", ""},
+	})
+	writeTestWorkbook(t, curPath, [][]string{
+		{"id", "name", "num", "items", "price"},
+		{"int", "string", "int", "[]int", "int"},
+		{"", "", "", "", ""},
+		{"道具 id", "名字", "数量", "物品", "价格"},
+		{"1001", "a", "5", "1|3|4", ""},
+		{"1003", "c", "x", "", ""},
+		{"1004", "d", "4", "", "10"},
+		{"1005", "e", "5", "", ""},
+	})
+
+	old, err := LoadXlsx(oldPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cur, err := LoadXlsx(curPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := DiffXlsx(old, cur)
+
+	var header []string
+	for _, h := range d.Header {
+		header = append(header, h.Kind+" "+h.Field+" "+h.Old+"→"+h.New)
+	}
+	var changed []string
+	for _, c := range d.Changed {
+		changed = append(changed, c.Id+" "+c.Path+" "+c.Old+"→"+c.New)
+	}
+	tests := []struct {
+		name string
+		got  string
+		want string
+	}{
+		{"header", strings.Join(header, ","), "desc name 名称→名字,added price →int"},
+		{"added", strings.Join(d.Added, ","), "1004"},
+		{"removed", strings.Join(d.Removed, ","), "1002"},
+		{"invalid", strings.Join(d.Invalid, ","), "1003"},
+		{"changed", strings.Join(changed, ","), "1001 num 1→5,1001 items[1] 2→3,1001 items[2] →4,1001 price →0,1005 price →0"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.name, tt.got, tt.want)
+		}
+	}
+	if len(d.Errors) != 1 || !strings.HasPrefix(d.Errors[0], "新版本: ") {
+		t.Errorf("errors = %q", d.Errors)
+	}
+	if text := d.Text(); !strings.Contains(text, "配置值错误(无法比较，见解析错误): 1003") || strings.Contains(text, "删除: 1002, 1003") {
+		t.Errorf("text = %s", text)
+	}
+	if d := DiffXlsx(old, old); !d.IsEmpty() {
+		t.Errorf("与自身比较 = %+v", d)
+	}
+}
@@ -1,30 +1,73 @@
 package core
 
 import (
-	"strconv"
+	"strings"
 )
 
 // methods
 func (f *Field) isHitMode(tMode string) bool {
-	if len(f.Mode) == 0 {
-		return true
+	if f.isReserved() {
+		// 保留列不导出
+		return false
 	}
-	if tMode == "server" && f.Mode == "s" {
+	if len(f.Mode) == 0 {
 		return true
 	}
-	if tMode == "client" && f.Mode == "c" {
-		return true
+	for _, t := range f.Targets {
+		if t == tMode {
+			return true
+		}
 	}
 	return false
 }
 
-func (f *Field) isVaildMode() bool {
-	switch f.Mode {
-	case "", "x", "s", "c":
-		return true
-	default:
+// 是否是保留列(__mode, __tag)
+func (f *Field) isReserved() bool {
+	x := f.Xlsx
+	return x != nil && (f == x.ModeField || f == x.TagField)
+}
+
+// 是否是单元格内联的复合类型(没有子字段列，值填写在同一个单元格中)
+// 后一列是元素列或子字段列时为元素列不匹配的多列字段(表头错误)，不是内联字段
+func (f *Field) isInline() bool {
+	if !f.isRecursice() || f.Index < 0 || len(f.Keys) > 0 || len(f.Vals) > 0 {
 		return false
 	}
+	return f.Xlsx == nil || !f.Xlsx.isElemColumn(f, f.Index+1)
+}
+
+// 字段在行中的值是否为空
+func (f *Field) isEmpty(row []string) bool {
+	if f.Index >= 0 && (f.isBuiltin() || f.Kind == TJson || f.isInline()) {
+		return f.Index >= len(row) || len(strings.TrimSpace(row[f.Index])) == 0
+	}
+	for _, k := range f.Keys {
+		if !k.isEmpty(row) {
+			return false
+		}
+	}
+	for _, v := range f.Vals {
+		if !v.isEmpty(row) {
+			return false
+		}
+	}
+	return true
+}
+
+// 数组元素列表，变长数组会去掉末尾的空元素
+func (f *Field) arrayVals(row []string) []*Field {
+	if f.Cap >= 0 {
+		return f.Vals
+	}
+	n := len(f.Vals)
+	for n > 0 && f.Vals[n-1].isEmpty(row) {
+		n--
+	}
+	return f.Vals[:n]
+}
+
+func (f *Field) isVaildMode() bool {
+	return isVaildModeTargets(f.Mode)
 }
 
 func (f *Field) checkRow(row []string, line int, x *Xlsx) bool {
@@ -33,6 +76,19 @@ func (f *Field) checkRow(row []string, line int, x *Xlsx) bool {
 		val = row[f.Index]
 	}
 
+	if f.Calc != nil || len(f.Expr) > 0 {
+		// 计算列的值由表达式重新计算(evalCalcFields)，单元格中原有的值不检查
+		return true
+	}
+
+	if f.isInline() {
+		if _, err := f.parseInline(val); err != nil {
+			x.sprintfCellError(line, f.Index+1, "单元格配置值错误: %v", err)
+			return false
+		}
+		return true
+	}
+
 	ok := true
 	errStr := "配置字段值错误"
 	switch f.Kind {
@@ -67,38 +123,20 @@ func (f *Field) checkRow(row []string, line int, x *Xlsx) bool {
 		if f.Vtype != nil && len(val) > 0 {
 			ok = f.Vtype.checkJsonVal(val)
 		}
-	case TUint:
-		if len(val) > 0 {
-			_, err := strconv.ParseUint(val, 10, 64)
-			if err != nil {
-				errStr = "无效的无符号整数值: " + err.Error()
-				ok = false
-			}
+	case TUint, TInt, TFloat, TBool:
+		if err := f.checkValue(val); err != nil {
+			errStr = err.Error()
+			ok = false
 		}
-	case TInt:
-		if len(val) > 0 {
-			_, err := strconv.ParseInt(val, 10, 64)
-			if err != nil {
-				errStr = "无效的整数值: " + err.Error()
-				ok = false
-			}
-		}
-	case TFloat:
-		if len(val) > 0 {
-			_, err := strconv.ParseFloat(val, 64)
-			if err != nil {
-				errStr = "无效的浮点数值: " + err.Error()
-				ok = false
-			}
+	case TString:
+		if f == x.ModeField && !isVaildModeTargets(strings.TrimSpace(val)) {
+			errStr = "行导出目标错误: " + val
+			ok = false
 		}
-	case TBool:
-		if len(val) > 0 {
-			if !(val == "0" || val == "1" || val == "true" || val == "false") {
-				errStr = "无效的布尔值: " + val
-				ok = false
-			}
+		if err := f.checkValue(val); ok && err != nil {
+			errStr = err.Error()
+			ok = false
 		}
-	case TString:
 		if ok && f.isI18nString() && len(val) > 0 {
 			i18nStr := getI18nString(val, f, line)
 			if len(i18nStr) > 0 {
@@ -8,6 +8,7 @@ import (
 )
 
 var Flaghelp bool
+var ConfigFile string // 项目配置文件(空=从当前目录向上查找 excelparser.yaml)
 
 // 字符串切片类型，用于接收命令行参数中的逗号分隔列表
 type StringFlagSlice []string
@@ -25,15 +26,26 @@ func (s *StringFlagSlice) Set(value string) error {
 func init() {
 	// flag
 	flag.BoolVar(&Flaghelp, "help", false, "Excelparser help.")
+	flag.StringVar(&ConfigFile, "config", "", "Project config file. Default: excelparser.yaml in current or parent directories.")
 	flag.BoolVar(&GFlags.Pretty, "indent", false, "Json indent flag.")
 	flag.BoolVar(&GFlags.Force, "force", false, "Force export of all excel files.")
 	flag.BoolVar(&GFlags.Compact, "compact", false, "Toggle compressed field content.")
-	flag.StringVar(&GFlags.Path, "path", "", "Excel input path.")
+	flag.BoolVar(&GFlags.Formula, "formula", false, "Recalculate formula cells instead of trusting cached values.")
+	flag.BoolVar(&GFlags.ErrorReport, "error-report", false, "Write a copy of each failing workbook to output/errors with error cells highlighted and commented.")
+	flag.BoolVar(&GFlags.LuaOpt, "lua-opt", false, "Optimize lua output: share repeated subtables and omit default fields via metatable.")
+	flag.Var((*StringFlagSlice)(&GFlags.LuaCols), "lua-columns", "Export lua tables in column layout (field list + positional rows), separated by comma, * for all. eg: item,hero")
+	flag.StringVar(&GFlags.Path, "path", "", "Input path (xlsx, ods, csv, tsv).")
 	flag.Var((*StringFlagSlice)(&GFlags.Client), "client", "Export client fields using the specified format, separated by comma. eg: lua,json")
 	flag.Var((*StringFlagSlice)(&GFlags.Server), "server", "Export server fields using the specified format, separated by comma. eg: lua,json")
+	flag.Var((*TargetFlagSlice)(&GFlags.Targets), "target", "Export a custom target as name:formats[:outdir], repeatable. eg: battle:lua,json")
 	flag.StringVar(&GFlags.I18nPath, "i18n", "./locales", "I18n po file path.")
 	flag.StringVar(&GFlags.I18nLang, "lang", "", "I18n language.")
 	flag.StringVar(&GFlags.Output, "output", ".", "Export output path.")
+	flag.Var((*StringFlagSlice)(&GFlags.Tags), "tags", "Export rows tagged with the specified tags in __tag column, separated by comma. eg: dev,debug")
+	flag.StringVar(&GFlags.Types, "types", "", "Shared type definition files (yaml or xlsx), separated by comma. Default: types.yaml or types@*.xlsx in input path.")
+	flag.StringVar(&GFlags.Bundle, "bundle", "", "Merge all tables of each target into one file with the specified name (lua, json, yaml, csharp). eg: config")
+	flag.StringVar(&GFlags.Compress, "compress", "", "Compress exported data files with the specified algorithm: gzip, zstd, lz4.")
+	flag.StringVar(&GFlags.EncryptKey, "encrypt-key", "", "Encrypt exported data files with AES-GCM, key file path or env:NAME. eg: ./table.key")
 	flag.Var((*StringFlagSlice)(&GFlags.Files), "files", "Specify excel files to export, separated by comma. eg: item@道具.xlsx,hero@英雄.xlsx")
 
 	flag.Usage = usage
@@ -42,12 +54,32 @@ func init() {
 func usage() {
 	fmt.Fprintf(os.Stderr, `excelparser version: 2025.0.1
     Usage: excelparser [OPTIONS]
+           excelparser [OPTIONS] COMMAND [ARGS]
     eg.: excelparser.exe --path=./xlsx --server=lua    --client=lua --output=./out
          excelparser.exe --path=./xlsx --server=json   --client=json --indent
          excelparser.exe --path=./xlsx --server=lua    --client=json --indent
          excelparser.exe --path=./xlsx --server=csharp --client=csharp --output=./out
          excelparser.exe --path=./xlsx --server=lua    --indent --i18n=./i18n --lang=en
-    Formats: lua, json, csharp (MessagePack binary + C# class)
+         excelparser.exe --path=./xlsx --server=lua    --target=battle:lua --target=gm:json:./gm
+         excelparser.exe --config=./excelparser.yaml --force
+    Formats: lua, json, yaml, toml, csharp (MessagePack binary + C# class), sqlite
+    Commands:
+         manifest-diff OLD NEW    Compare two manifest.json (or output dirs), print the patch list (A/M/D file)
+         diff [--json] OLD NEW    Semantic diff of two versions of a table (ids, cells, header)
+         diff [--json] --rev=REV FILE
+                                  Diff FILE at a git revision with the working tree
+         textconv FILE            Print a table as line-based text (git textconv driver)
+         merge [-o FILE] BASE OURS THEIRS [PATH]
+                                  Three-way merge of a table by id and field (git merge driver)
+         totext [-o DIR] PATH...  Convert workbooks (files or dirs) to reviewable text (*.xlsx.yaml)
+         fromtext [-o DIR] PATH...
+                                  Rebuild workbooks from text written by totext
+         new [-o FILE] [-type NAME] [-vertical] [-f] SCHEMA
+                                  Generate a table workbook from a schema (yaml/json) or go/c# type
+         annotate [-clear] PATH...
+                                  Write dropdowns, tooltips and error marks into workbooks in place
+         import [-o FILE] [-schema SCHEMA [-type NAME]] DATA [WORKBOOK]
+                                  Update (or create) a workbook from json/lua output rows by id
     Options:
 `)
 	flag.PrintDefaults()
@@ -10,6 +10,12 @@ func NewFormater(x *Xlsx, format, mode string) iFormater {
 		return &LuaFormater{Xlsx: x, mode: mode}
 	case "json":
 		return &JsonFormater{Xlsx: x, mode: mode}
+	case "yaml":
+		return &YamlFormater{Xlsx: x, mode: mode}
+	case "toml":
+		return &TomlFormater{Xlsx: x, mode: mode}
+	case "sqlite":
+		return &SqliteFormater{Xlsx: x, mode: mode}
 	case "csharp":
 		return &CSharpFormater{Xlsx: x, mode: mode}
 	default:
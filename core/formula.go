@@ -0,0 +1,76 @@
+// 公式单元格
+
+package core
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// 公式单元格坐标(line 为数据行号，i 为字段索引)
+func (x *Xlsx) formulaAxis(line, i int) string {
+	var axis string
+	if x.Vertical {
+		axis, _ = excelize.CoordinatesToCellName(line, i+1)
+	} else {
+		axis, _ = excelize.CoordinatesToCellName(i+1, line)
+	}
+	return axis
+}
+
+// 重新计算行内的公式单元格
+// 缓存值为空时使用计算值，缓存值与计算值不一致时报错
+// 输入格式不支持公式时(csv/tsv)不处理
+// 只计算字段索引在 [start, end) 范围内的单元格
+func (x *Xlsx) evalFormulas(row []string, line, start, end int) ([]string, bool) {
+	sheet, isFormulaSheet := x.Sheet.(formulaSheet)
+	if !isFormulaSheet {
+		return row, true
+	}
+	ok := true
+	for i := start; i < min(end, len(x.Types)); i++ {
+		axis := x.formulaAxis(line, i)
+		formula, err := sheet.GetCellFormula(axis)
+		if err != nil || len(formula) == 0 {
+			continue
+		}
+		if ExtRefRe.MatchString(formula) {
+			x.sprintfCellError(line, i+1, "公式引用了外部工作簿，无法计算: =%s", formula)
+			ok = false
+			continue
+		}
+
+		val, err := sheet.CalcCellValue(axis)
+		if err != nil {
+			x.sprintfCellError(line, i+1, "公式计算失败: %v", err)
+			ok = false
+			continue
+		}
+
+		for len(row) <= i {
+			row = append(row, "")
+		}
+		cached := row[i]
+		if len(cached) == 0 {
+			row[i] = val
+		} else if !isSameCellValue(cached, val) {
+			x.sprintfCellError(line, i+1, "公式缓存值[%s]与计算值[%s]不一致", cached, val)
+			ok = false
+		}
+	}
+	return row, ok
+}
+
+func isSameCellValue(a, b string) bool {
+	if a == b {
+		return true
+	}
+	fa, err1 := strconv.ParseFloat(a, 64)
+	fb, err2 := strconv.ParseFloat(b, 64)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	return math.Abs(fa-fb) <= 1e-9*math.Max(1, math.Abs(fa))
+}
@@ -37,9 +37,9 @@ func getI18nString(val string, f *Field, row int) string {
 	absXlsxPath, _ := filepath.Abs(GFlags.Path)
 	relpath, _ := filepath.Rel(absI18nPath, absXlsxPath)
 	if f.Xlsx.Vertical {
-		ref = fmt.Sprintf("%s%c%s:%s%d", relpath, filepath.Separator, f.Xlsx.Name, formatAxisX(row), 1)
+		ref = fmt.Sprintf("%s%c%s:%s%d", relpath, filepath.Separator, f.Xlsx.Name, formatAxisX(row), NameLine)
 	} else {
-		ref = fmt.Sprintf("%s%c%s:%s%d", relpath, filepath.Separator, f.Xlsx.Name, formatAxisX(f.Index+1), 1)
+		ref = fmt.Sprintf("%s%c%s:%s%d", relpath, filepath.Separator, f.Xlsx.Name, formatAxisX(f.Index+1), NameLine)
 	}
 
 	I18nLocale.AddRefs(val, ref)
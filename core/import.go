@@ -0,0 +1,732 @@
+// 由导出数据生成或更新配置表(import 命令)，json/lua 导出文件反向写回工作簿
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// 导入结果
+type ImportResult struct {
+	Updated int      // 更新的行数
+	Added   int      // 新增的行数
+	Cells   int      // 修改的单元格数
+	Errors  []string // 导入后的配置表检查错误
+}
+
+// 导入数据中的对象(保留键的顺序)
+type importObj struct {
+	keys []string
+	vals map[string]any
+}
+
+func (o *importObj) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(k)
+		val, err := json.Marshal(o.vals[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// 对象或数组的键值对列表(数组的键为从 1 开始的下标，与 lua 导出一致)
+func importEntries(v any) ([]string, []any, bool) {
+	switch val := v.(type) {
+	case *importObj:
+		vals := make([]any, 0, len(val.keys))
+		for _, k := range val.keys {
+			vals = append(vals, val.vals[k])
+		}
+		return val.keys, vals, true
+	case []any:
+		keys := make([]string, 0, len(val))
+		for i := range val {
+			keys = append(keys, strconv.Itoa(i+1))
+		}
+		return keys, val, true
+	}
+	return nil, nil, false
+}
+
+// 导入数据中的数组(空对象为空数组，lua 导出的空表无法区分)
+func importArray(v any) ([]any, bool) {
+	switch val := v.(type) {
+	case []any:
+		return val, true
+	case *importObj:
+		return nil, len(val.keys) == 0
+	case nil:
+		return nil, true
+	}
+	return nil, false
+}
+
+// 导入数据中的结构体
+func importStruct(v any) (*importObj, bool) {
+	switch val := v.(type) {
+	case *importObj:
+		return val, true
+	case []any:
+		return &importObj{vals: map[string]any{}}, len(val) == 0
+	case nil:
+		return &importObj{vals: map[string]any{}}, true
+	}
+	return nil, false
+}
+
+//#region MARK: 导入
+
+// 配置表导入
+type importer struct {
+	errors []string
+}
+
+func (im *importer) errorf(path, format string, a ...any) {
+	if len(im.errors) < MaxErrorCnt {
+		im.errors = append(im.errors, fmt.Sprintf("[%s]%s", path, fmt.Sprintf(format, a...)))
+	}
+}
+
+// ImportXlsx 将 json/lua 导出数据写入配置表，结果保存到 out
+// template 为使用相同表头的配置表，按 id 更新已有的行，新的 id 添加到末尾，数据中没有的行和字段不变；
+// 纵向表更新配置列。计算列、数据中没有的字段(导出模式不匹配)保持原值
+func ImportXlsx(dataPath, template, out string) (*ImportResult, error) {
+	text, err := os.ReadFile(dataPath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := parseImportData(text)
+	if err != nil {
+		return nil, fmt.Errorf("%s 解析失败: %v", dataPath, err)
+	}
+
+	x, err := LoadXlsx(template)
+	if err != nil {
+		return nil, err
+	}
+	for _, ce := range x.CellErrors {
+		if col, row := splitAxis(ce.Cell); ternary(x.Vertical, col, row) <= HeadLineNum {
+			return nil, fmt.Errorf("%s 表头错误: [%s]%s", template, ce.Cell, ce.Text)
+		}
+	}
+	f, err := excelize.OpenFile(template)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	opts := excelize.Options{RawCellValue: true}
+	var lines [][]string
+	if x.Vertical {
+		lines, err = f.GetCols(x.SheetName, opts)
+	} else {
+		lines, err = f.GetRows(x.SheetName, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for len(lines) < HeadLineNum {
+		lines = append(lines, nil)
+	}
+
+	// 转换为单元格值
+	im := &importer{}
+	ids := make([]string, 0)
+	records := make(map[string]map[int]string)
+	if x.Vertical {
+		cells := make(map[int]string)
+		im.setField(x.RootField, data, cells, "data")
+		ids = append(ids, "")
+		records[""] = cells
+	} else {
+		keys, vals, ok := importEntries(data)
+		if !ok {
+			return nil, errors.New("横向表的导入数据必须为以 id 为键的对象")
+		}
+		keyField := x.RootField.Vals[0]
+		for i, id := range keys {
+			cells := make(map[int]string)
+			im.setField(x.RootField, vals[i], cells, id)
+			if v, ok := cells[keyField.Index]; !ok {
+				cells[keyField.Index] = id
+			} else if keyField.formatValue(v) != keyField.formatValue(id) {
+				im.errorf(id, "id 与键不一致: %s", v)
+			}
+			ids = append(ids, id)
+			records[id] = cells
+		}
+	}
+	if len(im.errors) > 0 {
+		return nil, errors.New(strings.Join(im.errors, "\n"))
+	}
+
+	// 字段列(用于比较单元格值)
+	fields := make(map[int]*Field)
+	var walk func(field *Field)
+	walk = func(field *Field) {
+		if field.Index >= 0 {
+			fields[field.Index] = field
+		}
+		for _, v := range append(field.Keys, field.Vals...) {
+			walk(v)
+		}
+	}
+	walk(x.RootField)
+
+	// 已有的行
+	existing := make(map[string]int)
+	if x.Vertical {
+		existing[""] = HeadLineNum
+	} else {
+		for i := HeadLineNum; i < len(lines); i++ {
+			id := strings.TrimSpace(cellOf(lines[i], 0))
+			if _, ok := existing[id]; !ok && id != "" && !strings.HasPrefix(id, "//") {
+				existing[id] = i
+			}
+		}
+	}
+
+	result := &ImportResult{}
+	next := len(lines)
+	for _, id := range ids {
+		li, ok := existing[id]
+		if !ok {
+			li = next
+			next++
+		}
+		var line []string
+		if li < len(lines) {
+			line = lines[li]
+		}
+		cols := make([]int, 0, len(records[id]))
+		for col := range records[id] {
+			cols = append(cols, col)
+		}
+		slices.Sort(cols)
+		changed := 0
+		for _, col := range cols {
+			v, old := records[id][col], cellOf(line, col)
+			field := fields[col]
+			if sameCellValue(field, old, v) {
+				continue
+			}
+			axis, _ := excelize.CoordinatesToCellName(ternary(x.Vertical, li, col)+1, ternary(x.Vertical, col, li)+1)
+			switch {
+			case len(v) == 0:
+				f.SetCellValue(x.SheetName, axis, nil)
+			case field != nil && field.isBuiltin() && field.Kind != TString:
+				setTextCell(f, x.SheetName, axis, v)
+			default:
+				f.SetCellStr(x.SheetName, axis, v)
+			}
+			changed++
+		}
+		result.Cells += changed
+		if !ok {
+			result.Added++
+		} else if changed > 0 {
+			result.Updated++
+		}
+	}
+	if err := f.SaveAs(out); err != nil {
+		return nil, err
+	}
+
+	// 检查导入后的配置表
+	if x, err := LoadXlsx(out); err != nil {
+		result.Errors = []string{err.Error()}
+	} else {
+		result.Errors = x.Errors
+	}
+	return result, nil
+}
+
+// 字段值转换为单元格值(列下标 -> 单元格值)
+func (im *importer) setField(field *Field, v any, cells map[int]string, path string) {
+	if field.Calc != nil || len(field.Expr) > 0 || field.isReserved() {
+		// 计算列、保留列不导入
+		return
+	}
+	if field.Parent != nil && len(field.Name) > 0 && field.Parent.Kind == TStruct {
+		path += "." + field.Name
+	}
+
+	switch {
+	case field.isInline():
+		s, err := inlineText(field.Type, v, true)
+		if err == nil {
+			_, err = field.parseInline(s)
+		}
+		if err != nil {
+			im.errorf(path, "%v", err)
+			return
+		}
+		cells[field.Index] = s
+	case field.Kind == TJson:
+		s := ""
+		if v != nil {
+			b, err := json.Marshal(v)
+			if err != nil {
+				im.errorf(path, "%v", err)
+				return
+			}
+			s = string(b)
+		}
+		cells[field.Index] = s
+	case field.Kind == TArray:
+		elems, ok := importArray(v)
+		if !ok {
+			im.errorf(path, "值不是数组")
+			return
+		}
+		if len(elems) > len(field.Vals) {
+			im.errorf(path, "数组元素个数%d超过元素列数%d", len(elems), len(field.Vals))
+			return
+		}
+		for i, e := range field.Vals {
+			if i < len(elems) {
+				im.setField(e, elems[i], cells, fmt.Sprintf("%s[%d]", path, i+1))
+			} else {
+				clearFieldCells(e, cells)
+			}
+		}
+	case field.Kind == TMap:
+		keys, vals, ok := importEntries(v)
+		if v == nil {
+			ok = true
+		}
+		if !ok {
+			im.errorf(path, "值不是 map")
+			return
+		}
+		if len(keys) > len(field.Keys) {
+			im.errorf(path, "map 元素个数%d超过元素列数%d", len(keys), len(field.Keys))
+			return
+		}
+		for i, k := range field.Keys {
+			if i < len(keys) {
+				im.setField(k, keys[i], cells, path)
+				im.setField(field.Vals[i], vals[i], cells, fmt.Sprintf("%s[%s]", path, keys[i]))
+			} else {
+				clearFieldCells(k, cells)
+				clearFieldCells(field.Vals[i], cells)
+			}
+		}
+	case field.Kind == TStruct:
+		obj, ok := importStruct(v)
+		if !ok {
+			im.errorf(path, "值不是结构体")
+			return
+		}
+		for _, k := range obj.keys {
+			if !slices.ContainsFunc(field.Vals, func(f *Field) bool { return f.Name == k }) {
+				im.errorf(path, "字段[%s]不存在", k)
+			}
+		}
+		for _, sub := range field.Vals {
+			if val, ok := obj.vals[sub.Name]; ok {
+				im.setField(sub, val, cells, path)
+			}
+		}
+	default:
+		s, err := scalarText(field.Type, v)
+		if err != nil {
+			im.errorf(path, "%v", err)
+			return
+		}
+		cells[field.Index] = s
+	}
+}
+
+// 单元格值是否相同(基础类型比较导出值，json 比较解析后的值)
+func sameCellValue(field *Field, a, b string) bool {
+	switch {
+	case a == b:
+		return true
+	case field == nil:
+		return false
+	case field.isBuiltin():
+		return field.formatValue(a) == field.formatValue(b)
+	case field.Kind == TJson:
+		var av, bv any
+		return json.Unmarshal([]byte(a), &av) == nil && json.Unmarshal([]byte(b), &bv) == nil && reflect.DeepEqual(av, bv)
+	}
+	return false
+}
+
+// 清空字段的所有值单元格(数组/map 多余的元素列)
+func clearFieldCells(field *Field, cells map[int]string) {
+	if field.Calc != nil || len(field.Expr) > 0 {
+		return
+	}
+	if field.Index >= 0 && (field.isBuiltin() || field.Kind == TJson || field.Kind == TAny || field.isInline()) {
+		cells[field.Index] = ""
+		return
+	}
+	for _, v := range append(field.Keys, field.Vals...) {
+		clearFieldCells(v, cells)
+	}
+}
+
+// 基础类型值的文本
+func scalarText(t *Type, v any) (string, error) {
+	var s string
+	switch val := v.(type) {
+	case nil:
+	case string:
+		s = val
+	case json.Number:
+		s = val.String()
+	case bool:
+		s = strconv.FormatBool(val)
+	default:
+		return "", errors.New("值不是基础类型")
+	}
+	if err := t.checkValue(s); err != nil {
+		return "", err
+	}
+	return s, nil
+}
+
+// 单元格内联值的文本(与 parseInline 对应)
+func inlineText(t *Type, v any, top bool) (string, error) {
+	switch t.Kind {
+	case TArray:
+		elems, ok := importArray(v)
+		if !ok {
+			return "", errors.New("值不是数组")
+		}
+		parts := make([]string, 0, len(elems))
+		for _, e := range elems {
+			s, err := inlineText(t.Vtype, e, false)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, s)
+		}
+		s := strings.Join(parts, "|")
+		if !top || strings.HasPrefix(s, "[") {
+			s = "[" + s + "]"
+		}
+		return s, nil
+	case TMap:
+		keys, vals, ok := importEntries(v)
+		if !ok && v != nil {
+			return "", errors.New("值不是 map")
+		}
+		parts := make([]string, 0, len(keys))
+		for i, k := range keys {
+			ks, err := inlineText(t.Ktype, k, false)
+			if err != nil {
+				return "", err
+			}
+			vs, err := inlineText(t.Vtype, vals[i], false)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, ks+":"+vs)
+		}
+		return strings.Join(parts, ";"), nil
+	case TStruct:
+		obj, ok := importStruct(v)
+		if !ok {
+			return "", errors.New("值不是结构体")
+		}
+		parts := make([]string, 0, len(t.Fnames))
+		for _, name := range t.Fnames {
+			if val, ok := obj.vals[name]; ok {
+				s, err := inlineText(t.Ftypes[name], val, false)
+				if err != nil {
+					return "", err
+				}
+				parts = append(parts, name+"="+s)
+			}
+		}
+		return "{" + strings.Join(parts, ",") + "}", nil
+	default:
+		s, err := scalarText(t, v)
+		if err == nil && strings.ContainsAny(s, "|,;:={}[]") {
+			err = fmt.Errorf("值[%s]包含分隔符，不能填写在单元格中", s)
+		}
+		return s, err
+	}
+}
+
+//#endregion
+
+//#region MARK: 数据解析
+
+// 解析 json/lua 导出数据
+// lua 为 local t = {...} 或 return {...} 形式的表构造(不支持 lua-opt 和列布局导出)，
+// 连续整数键 [1]..[n] 的表为数组；字符串中未转义的换行也可以解析(与导出一致)
+func parseImportData(data []byte) (any, error) {
+	p := &dataParser{s: bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))}
+	p.skipSpace()
+	if p.ident() == "local" {
+		p.pos += len("local")
+		p.skipSpace()
+		p.pos += len(p.ident())
+		p.skipSpace()
+		if !p.consume('=') {
+			return nil, p.errorf("缺少 =")
+		}
+	} else if p.ident() == "return" {
+		p.pos += len("return")
+	}
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Contains(p.s[p.pos:], []byte("setmetatable")) {
+		return nil, errors.New("不支持 lua-opt 和列布局导出的 lua 文件")
+	}
+	return v, nil
+}
+
+type dataParser struct {
+	s   []byte
+	pos int
+}
+
+func (p *dataParser) errorf(format string, a ...any) error {
+	line := bytes.Count(p.s[:min(p.pos, len(p.s))], []byte("\n")) + 1
+	return fmt.Errorf("第%d行: %s", line, fmt.Sprintf(format, a...))
+}
+
+// 跳过空白和 lua 注释
+func (p *dataParser) skipSpace() {
+	for p.pos < len(p.s) {
+		switch {
+		case unicode.IsSpace(rune(p.s[p.pos])):
+			p.pos++
+		case bytes.HasPrefix(p.s[p.pos:], []byte("--")):
+			if i := bytes.IndexByte(p.s[p.pos:], '\n'); i >= 0 {
+				p.pos += i + 1
+			} else {
+				p.pos = len(p.s)
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *dataParser) consume(ch byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.s) && p.s[p.pos] == ch {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *dataParser) ident() string {
+	i := p.pos
+	for i < len(p.s) && (p.s[i] == '_' || p.s[i] >= 0x80 || unicode.IsLetter(rune(p.s[i])) || (i > p.pos && unicode.IsDigit(rune(p.s[i])))) {
+		i++
+	}
+	return string(p.s[p.pos:i])
+}
+
+func (p *dataParser) value() (any, error) {
+	p.skipSpace()
+	if p.pos >= len(p.s) {
+		return nil, p.errorf("数据不完整")
+	}
+	switch ch := p.s[p.pos]; {
+	case ch == '{':
+		return p.table()
+	case ch == '[':
+		p.pos++
+		arr := make([]any, 0)
+		if p.consume(']') {
+			return arr, nil
+		}
+		for {
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+			if p.consume(']') {
+				return arr, nil
+			}
+			if !p.consume(',') {
+				return nil, p.errorf("数组缺少 , 或 ]")
+			}
+		}
+	case ch == '"' || ch == '\'':
+		return p.str()
+	case ch == '-' || ch == '+' || ch == '.' || (ch >= '0' && ch <= '9'):
+		i := p.pos
+		for i < len(p.s) && strings.IndexByte("+-.0123456789eE", p.s[i]) >= 0 {
+			i++
+		}
+		num := strings.TrimPrefix(string(p.s[p.pos:i]), "+")
+		if _, err := strconv.ParseFloat(num, 64); err != nil {
+			return nil, p.errorf("数值格式错误[%s]", num)
+		}
+		p.pos = i
+		return json.Number(num), nil
+	default:
+		word := p.ident()
+		p.pos += len(word)
+		switch word {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null", "nil":
+			return nil, nil
+		}
+		return nil, p.errorf("不支持的值[%s]", word)
+	}
+}
+
+// 对象(json)或表构造(lua)
+func (p *dataParser) table() (any, error) {
+	p.pos++
+	obj := &importObj{vals: make(map[string]any)}
+	seq := true // lua 连续整数键 [1]..[n] 或者没有键的表为数组
+	for !p.consume('}') {
+		p.skipSpace()
+		var key string
+		implicit := false
+		switch {
+		case p.pos < len(p.s) && p.s[p.pos] == '[':
+			// lua [key] =
+			p.pos++
+			if !p.consume(']') {
+				k, err := p.value()
+				if err != nil {
+					return nil, err
+				}
+				if !p.consume(']') {
+					return nil, p.errorf("表键格式错误")
+				}
+				key = fmt.Sprint(k)
+			}
+			if !p.consume('=') {
+				return nil, p.errorf("表键格式错误")
+			}
+			seq = seq && key == strconv.Itoa(len(obj.keys)+1)
+		case p.pos < len(p.s) && (p.s[p.pos] == '"' || p.s[p.pos] == '\''):
+			// json "key":
+			k, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			if !p.consume(':') && !p.consume('=') {
+				return nil, p.errorf("对象键[%s]后缺少 :", k)
+			}
+			key = k
+			seq = false
+		default:
+			word := p.ident()
+			start := p.pos
+			p.pos += len(word)
+			if len(word) > 0 && p.consume('=') {
+				// lua key =
+				key = word
+				seq = false
+			} else {
+				// lua 数组元素
+				p.pos = start
+				key = strconv.Itoa(len(obj.keys) + 1)
+				implicit = true
+			}
+		}
+		if _, ok := obj.vals[key]; ok && !implicit {
+			return nil, p.errorf("键[%s]重复", key)
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if len(key) > 0 {
+			// 键为空的是多列 map 中未填写的元素
+			obj.keys = append(obj.keys, key)
+			obj.vals[key] = v
+		}
+		if !p.consume(',') && !p.consume(';') {
+			if !p.consume('}') {
+				return nil, p.errorf("缺少 , 或 }")
+			}
+			break
+		}
+	}
+	if seq && len(obj.keys) > 0 {
+		arr := make([]any, 0, len(obj.keys))
+		for _, k := range obj.keys {
+			arr = append(arr, obj.vals[k])
+		}
+		return arr, nil
+	}
+	return obj, nil
+}
+
+func (p *dataParser) str() (string, error) {
+	quote := p.s[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.s) {
+		ch := p.s[p.pos]
+		p.pos++
+		switch {
+		case ch == quote:
+			return sb.String(), nil
+		case ch == '\\' && p.pos < len(p.s):
+			esc := p.s[p.pos]
+			p.pos++
+			switch esc {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case 'r':
+				sb.WriteByte('\r')
+			case 'b':
+				sb.WriteByte('\b')
+			case 'f':
+				sb.WriteByte('\f')
+			case 'u':
+				if p.pos+4 <= len(p.s) {
+					if r, err := strconv.ParseUint(string(p.s[p.pos:p.pos+4]), 16, 32); err == nil {
+						sb.WriteRune(rune(r))
+						p.pos += 4
+						continue
+					}
+				}
+				sb.WriteString(`\u`)
+			default:
+				sb.WriteByte(esc)
+			}
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	return "", p.errorf("字符串缺少结束引号")
+}
+
+//#endregion
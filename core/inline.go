@@ -0,0 +1,239 @@
+// 单元格内联值
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 单元格内联值
+// 复合类型字段没有子字段列时，值填写在同一个单元格中
+// eg.: []int 填写 1|2|3 或 1,2,3
+// eg.: map[int]int 填写 1:10;2:20
+// eg.: {id=int,num=int} 填写 {id=1001,num=3}
+type inlineValue struct {
+	Val  string         // 基础类型值
+	Keys []*inlineValue // 键列表(for map)
+	Vals []*inlineValue // 元素列表(for array,map)，字段值列表(for struct，与 Type.Fnames 一一对应)
+}
+
+// 解析字段单元格中的内联值
+func (f *Field) parseInline(val string) (*inlineValue, error) {
+	return parseInline(f.Type, strings.TrimSpace(val))
+}
+
+// 解析行中字段的内联值(行数据已经过 checkRow 检查)
+func (f *Field) inlineOf(row []string) *inlineValue {
+	s := ""
+	if f.Index < len(row) {
+		s = row[f.Index]
+	}
+	v, err := f.parseInline(s)
+	if err != nil {
+		return &inlineValue{}
+	}
+	return v
+}
+
+// 检查类型是否支持单元格内联
+func checkInlineType(t *Type) error {
+	switch t.Kind {
+	case TArray:
+		return checkInlineType(t.Vtype)
+	case TMap:
+		if !t.Ktype.isBuiltin() {
+			return errors.New("单元格map的键必须为基础类型")
+		}
+		return checkInlineType(t.Vtype)
+	case TStruct:
+		if len(t.Fnames) == 0 {
+			return errors.New("单元格结构体没有字段定义")
+		}
+		for _, name := range t.Fnames {
+			if err := checkInlineType(t.Ftypes[name]); err != nil {
+				return err
+			}
+		}
+	case TAny, TJson:
+		return errors.New("单元格复合类型不支持any和json")
+	case TString:
+		if t.I18n {
+			return errors.New("单元格复合类型不支持i18n")
+		}
+	}
+	return nil
+}
+
+func parseInline(t *Type, s string) (*inlineValue, error) {
+	switch t.Kind {
+	case TArray:
+		return parseInlineArray(t, s)
+	case TMap:
+		return parseInlineMap(t, s)
+	case TStruct:
+		return parseInlineStruct(t, s)
+	default:
+		if err := t.checkValue(s); err != nil {
+			return nil, err
+		}
+		return &inlineValue{Val: s}, nil
+	}
+}
+
+// 数组元素使用 | 或 , 分隔，优先使用 |，嵌套数组可以使用 [] 包裹
+// 变长数组会去掉末尾的空元素，定长数组元素个数不能超过数组长度，不足时使用默认值填充
+func parseInlineArray(t *Type, s string) (*inlineValue, error) {
+	if isInlineWrapped(s, '[', ']') {
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+
+	v := &inlineValue{}
+	var elems []string
+	if len(s) > 0 {
+		sep := byte(',')
+		if len(splitInline(s, '|')) > 1 {
+			sep = '|'
+		}
+		elems = splitInline(s, sep)
+	}
+	if t.Cap < 0 {
+		for len(elems) > 0 && len(elems[len(elems)-1]) == 0 {
+			elems = elems[:len(elems)-1]
+		}
+	} else if len(elems) > t.Cap {
+		return nil, fmt.Errorf("数组元素个数%d超过数组长度%d", len(elems), t.Cap)
+	}
+
+	for _, e := range elems {
+		ev, err := parseInline(t.Vtype, e)
+		if err != nil {
+			return nil, err
+		}
+		v.Vals = append(v.Vals, ev)
+	}
+	for len(v.Vals) < t.Cap {
+		ev, _ := parseInline(t.Vtype, "")
+		v.Vals = append(v.Vals, ev)
+	}
+	return v, nil
+}
+
+// map 键值对使用 ; 分隔，键和值使用 : 分隔
+func parseInlineMap(t *Type, s string) (*inlineValue, error) {
+	v := &inlineValue{}
+	if len(s) == 0 {
+		return v, nil
+	}
+
+	keys := make(map[string]bool)
+	for _, e := range splitInline(s, ';') {
+		if len(e) == 0 {
+			continue
+		}
+		kv := splitInlineN(e, ':', 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("map键值对格式错误[%s]", e)
+		}
+		kv[0] = strings.TrimSpace(kv[0])
+		if keys[kv[0]] {
+			return nil, fmt.Errorf("map键[%s]重复", kv[0])
+		}
+		keys[kv[0]] = true
+
+		kval, err := parseInline(t.Ktype, kv[0])
+		if err != nil {
+			return nil, err
+		}
+		vval, err := parseInline(t.Vtype, strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, err
+		}
+		v.Keys = append(v.Keys, kval)
+		v.Vals = append(v.Vals, vval)
+	}
+	return v, nil
+}
+
+// 结构体使用 {} 包裹，字段使用 , 分隔，字段名和值使用 = 分隔，未填写的字段使用默认值
+func parseInlineStruct(t *Type, s string) (*inlineValue, error) {
+	fields := make(map[string]string)
+	if len(s) > 0 {
+		if !isInlineWrapped(s, '{', '}') {
+			return nil, fmt.Errorf("结构体格式错误[%s]", s)
+		}
+		for _, e := range splitInline(s[1:len(s)-1], ',') {
+			if len(e) == 0 {
+				continue
+			}
+			kv := splitInlineN(e, '=', 2)
+			if len(kv) != 2 {
+				return nil, fmt.Errorf("结构体字段格式错误[%s]", e)
+			}
+			name := strings.TrimSpace(kv[0])
+			if _, ok := t.Ftypes[name]; !ok {
+				return nil, fmt.Errorf("结构体字段[%s]不存在", name)
+			}
+			if _, ok := fields[name]; ok {
+				return nil, fmt.Errorf("结构体字段[%s]重复", name)
+			}
+			fields[name] = strings.TrimSpace(kv[1])
+		}
+	}
+
+	v := &inlineValue{}
+	for _, name := range t.Fnames {
+		fv, err := parseInline(t.Ftypes[name], fields[name])
+		if err != nil {
+			return nil, err
+		}
+		v.Vals = append(v.Vals, fv)
+	}
+	return v, nil
+}
+
+// 字符串是否整体被一对括号包裹(eg.: [1,2] 是，[1,2]|[3] 不是)
+func isInlineWrapped(s string, open, close byte) bool {
+	if len(s) < 2 || s[0] != open || s[len(s)-1] != close {
+		return false
+	}
+	depth := 0
+	for i := 0; i < len(s)-1; i++ {
+		switch s[i] {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		}
+		if depth == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// 按分隔符分割字符串，忽略 {} 和 [] 中的分隔符
+func splitInline(s string, sep byte) []string {
+	return splitInlineN(s, sep, -1)
+}
+
+func splitInlineN(s string, sep byte, n int) []string {
+	var result []string
+	depth := 0
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case sep:
+			if depth == 0 && (n < 0 || len(result) < n-1) {
+				result = append(result, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(result, strings.TrimSpace(s[start:]))
+}
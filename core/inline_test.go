@@ -0,0 +1,111 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+// 内联值的文本形式: 数组为 [a b]，map 为 {k:v k:v}，结构体为 {name=v name=v}
+func inlineString(t *Type, v *inlineValue) string {
+	switch t.Kind {
+	case TArray:
+		elems := make([]string, 0, len(v.Vals))
+		for _, e := range v.Vals {
+			elems = append(elems, inlineString(t.Vtype, e))
+		}
+		return "[" + strings.Join(elems, " ") + "]"
+	case TMap:
+		elems := make([]string, 0, len(v.Vals))
+		for i, e := range v.Vals {
+			elems = append(elems, inlineString(t.Ktype, v.Keys[i])+":"+inlineString(t.Vtype, e))
+		}
+		return "{" + strings.Join(elems, " ") + "}"
+	case TStruct:
+		elems := make([]string, 0, len(v.Vals))
+		for i, name := range t.Fnames {
+			elems = append(elems, name+"="+inlineString(t.Ftypes[name], v.Vals[i]))
+		}
+		return "{" + strings.Join(elems, " ") + "}"
+	default:
+		return v.Val
+	}
+}
+
+func TestParseInline(t *testing.T) {
+	tests := []struct {
+		typ  string
+		val  string
+		want string
+		err  string
+	}{
+		{typ: "[]int", val: "1|2|3", want: "[1 2 3]"},
+		{typ: "[]int", val: "1,2,3", want: "[1 2 3]"},
+		{typ: "[]int", val: " 1 | 2 ", want: "[1 2]"},
+		{typ: "[]int", val: "1|2||", want: "[1 2]"},
+		{typ: "[]int", val: "", want: "[]"},
+		{typ: "[]string", val: "a,b|c", want: "[a,b c]"},
+		{typ: "[3]int", val: "1|2", want: "[1 2 ]"},
+		{typ: "[2]int", val: "1|2|3", err: "超过数组长度"},
+		{typ: "[]int", val: "1|x", err: "无效的整数值"},
+		{typ: "[]uint", val: "-1", err: "无效的无符号整数值"},
+		{typ: "[]bool", val: "true,0", want: "[true 0]"},
+		{typ: "[][]int", val: "[1,2]|[3]", want: "[[1 2] [3]]"},
+		{typ: "map[int]int", val: "1:10;2:20", want: "{1:10 2:20}"},
+		{typ: "map[int]int", val: " 1 : 10 ; ", want: "{1:10}"},
+		{typ: "map[int]int", val: "", want: "{}"},
+		{typ: "map[string][]int", val: "a:1|2;b:3", want: "{a:[1 2] b:[3]}"},
+		{typ: "map[int]int", val: "1:10;1:20", err: "map键[1]重复"},
+		{typ: "map[int]int", val: "1=10", err: "map键值对格式错误"},
+		{typ: "map[int]int", val: "x:10", err: "无效的整数值"},
+		{typ: "{id=int,num=int}", val: "{id=1001,num=3}", want: "{id=1001 num=3}"},
+		{typ: "{id=int,num=int}", val: "{num=3}", want: "{id= num=3}"},
+		{typ: "{id=int,num=int}", val: "", want: "{id= num=}"},
+		{typ: "{id=int,items=[]int}", val: "{id=1,items=[1,2]}", want: "{id=1 items=[1 2]}"},
+		{typ: "[]{id=int,num=int}", val: "{id=1,num=2}|{id=2}", want: "[{id=1 num=2} {id=2 num=}]"},
+		{typ: "{id=int,num=int}", val: "id=1", err: "结构体格式错误"},
+		{typ: "{id=int,num=int}", val: "{id=1},{id=2}", err: "结构体格式错误"},
+		{typ: "{id=int,num=int}", val: "{cnt=1}", err: "结构体字段[cnt]不存在"},
+		{typ: "{id=int,num=int}", val: "{id=1,id=2}", err: "结构体字段[id]重复"},
+		{typ: "{id=int,num=int}", val: "{id}", err: "结构体字段格式错误"},
+	}
+	for _, tt := range tests {
+		typ := parseType(tt.typ)
+		v, err := parseInline(typ, tt.val)
+		if len(tt.err) > 0 {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s %q: error %v, want %q", tt.typ, tt.val, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: unexpected error %v", tt.typ, tt.val, err)
+		} else if got := inlineString(typ, v); got != tt.want {
+			t.Errorf("%s %q = %s, want %s", tt.typ, tt.val, got, tt.want)
+		}
+	}
+}
+
+func TestCheckInlineType(t *testing.T) {
+	tests := []struct {
+		typ string
+		err string
+	}{
+		{typ: "[]int"},
+		{typ: "map[string]{id=int,num=int}"},
+		{typ: "[]{id=int,items=[]int}"},
+		{typ: "map[[]int]int", err: "键必须为基础类型"},
+		{typ: "[]any", err: "不支持any和json"},
+		{typ: "[]i18n", err: "不支持i18n"},
+		{typ: "struct#Reward", err: "没有字段定义"},
+	}
+	for _, tt := range tests {
+		err := checkInlineType(parseType(tt.typ))
+		if len(tt.err) == 0 {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.typ, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s: error %v, want %q", tt.typ, err, tt.err)
+		}
+	}
+}
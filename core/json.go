@@ -18,6 +18,7 @@ func (j *JsonFormater) formatRows() {
 			j.formatData(j.RootField, col, 0)
 		}
 	} else {
+		cnt := 0
 		j.appendData("{\n")
 		for _, row := range j.Rows {
 			j.line++
@@ -25,6 +26,10 @@ func (j *JsonFormater) formatRows() {
 			if strings.HasPrefix(key, "//") || key == "" {
 				continue
 			}
+			if !j.isRowHit(row, j.mode) {
+				continue
+			}
+			cnt++
 			j.appendIndent(1)
 			j.appendData("\"")
 			j.appendData(key)
@@ -32,19 +37,26 @@ func (j *JsonFormater) formatRows() {
 			j.formatData(j.RootField, row, 1)
 			j.appendData(",\n")
 		}
-		j.replaceTail("\n")
+		if cnt > 0 {
+			j.replaceTail("\n")
+		}
 		j.appendData("}")
 	}
 }
 
 // datas
 func (j *JsonFormater) formatData(field *Field, row []string, depth int) {
+	if field.isInline() {
+		j.formatInline(field.Type, field.inlineOf(row), depth)
+		return
+	}
+
 	fkind := field.Kind
 	switch fkind {
 	case TArray:
 		j.appendData("[")
 		j.appendEOL()
-		for _, f := range field.Vals {
+		for _, f := range field.arrayVals(row) {
 			j.appendIndent(depth + 1)
 			j.formatData(f, row, depth+1)
 			j.appendComma()
@@ -97,10 +109,10 @@ func (j *JsonFormater) formatData(field *Field, row []string, depth int) {
 				}
 			}
 			var out bytes.Buffer
-			if GFlags.Compact {
+			if j.Compact {
 				json.Compact(&out, []byte(s))
 				j.appendData(out.String())
-			} else if GFlags.Pretty {
+			} else if j.Pretty {
 				json.Indent(&out, []byte(s), getIndent(depth), "  ")
 				j.appendData(out.String())
 			} else {
@@ -119,6 +131,53 @@ func (j *JsonFormater) formatData(field *Field, row []string, depth int) {
 	}
 }
 
+// 单元格内联值
+func (j *JsonFormater) formatInline(t *Type, v *inlineValue, depth int) {
+	switch t.Kind {
+	case TArray:
+		j.appendData("[")
+		j.appendEOL()
+		for _, e := range v.Vals {
+			j.appendIndent(depth + 1)
+			j.formatInline(t.Vtype, e, depth+1)
+			j.appendComma()
+		}
+		j.replaceComma()
+		j.appendIndent(depth)
+		j.appendData("]")
+	case TMap:
+		j.appendData("{")
+		j.appendEOL()
+		for i, k := range v.Keys {
+			j.appendIndent(depth + 1)
+			j.appendData("\"")
+			j.appendData(k.Val)
+			j.appendData("\":")
+			j.formatInline(t.Vtype, v.Vals[i], depth+1)
+			j.appendComma()
+		}
+		j.replaceComma()
+		j.appendIndent(depth)
+		j.appendData("}")
+	case TStruct:
+		j.appendData("{")
+		j.appendEOL()
+		for i, name := range t.Fnames {
+			j.appendIndent(depth + 1)
+			j.appendData("\"")
+			j.appendData(name)
+			j.appendData("\":")
+			j.formatInline(t.Ftypes[name], v.Vals[i], depth+1)
+			j.appendComma()
+		}
+		j.replaceComma()
+		j.appendIndent(depth)
+		j.appendData("}")
+	default:
+		j.appendData(t.formatValue(v.Val))
+	}
+}
+
 func (j *JsonFormater) updateI18nJson(field *Field, t *Type, obj any) {
 	var vt *Type
 	if t != nil {
@@ -3,8 +3,13 @@ package core
 import (
 	"encoding/json"
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 // 类型检查(例如: int 类型的字段填了 string， 耗性能)
@@ -15,6 +20,14 @@ func (l *LuaFormater) formatRows() {
 	l.clearData()
 
 	l.appendData("-- Auto generated by excelparser. DO NOT EDIT!\n\n")
+	if l.isLuaColumns() {
+		l.formatColumnRows()
+		return
+	}
+	if GFlags.LuaOpt && !l.Vertical {
+		l.formatOptRows()
+		return
+	}
 	l.appendData(l.formatLuaComment(l.mode))
 
 	// data
@@ -25,6 +38,7 @@ func (l *LuaFormater) formatRows() {
 			l.formatData(l.RootField, col, 0)
 		}
 	} else {
+		cnt := 0
 		l.appendData("\nlocal t = {\n")
 		for _, row := range l.Rows {
 			l.line++
@@ -32,6 +46,10 @@ func (l *LuaFormater) formatRows() {
 			if strings.HasPrefix(key, "//") || key == "" {
 				continue
 			}
+			if !l.isRowHit(row, l.mode) {
+				continue
+			}
+			cnt++
 			l.appendIndent(1)
 			l.appendData("[")
 			l.appendData(row[0])
@@ -42,21 +60,263 @@ func (l *LuaFormater) formatRows() {
 			l.formatData(l.RootField, row, 1)
 			l.appendData(",\n")
 		}
+		if cnt > 0 {
+			l.replaceTail("\n")
+		}
+		l.appendData("}")
+	}
+	l.appendData("\nreturn t")
+}
+
+// 优化导出(横向表)
+// 多行中重复出现的子表只生成一次，保存在 _S 中共享引用(共享子表不能修改)；
+// 基础类型字段的值等于默认值时不导出，由元表 {__index = _D} 提供
+func (l *LuaFormater) formatOptRows() {
+	fields := make([]*Field, 0, len(l.RootField.Vals))
+	for _, f := range l.RootField.Vals {
+		if f.isHitMode(l.mode) {
+			fields = append(fields, f)
+		}
+	}
+
+	type optRow struct {
+		line int
+		row  []string
+		vals []string // 字段值(nil 表示使用默认值)
+	}
+	rows := make([]*optRow, 0, len(l.Rows))
+	tables := make(map[string]int)        // 子表 -> 出现次数
+	defaults := make([]bool, len(fields)) // 字段是否有使用默认值的行
+	for _, row := range l.Rows {
+		l.line++
+		key := row[0]
+		if strings.HasPrefix(key, "//") || key == "" {
+			continue
+		}
+		if !l.isRowHit(row, l.mode) {
+			continue
+		}
+
+		r := &optRow{line: l.line, row: row, vals: make([]string, len(fields))}
+		for i, f := range fields {
+			if f.isBuiltin() {
+				s := ""
+				if f.Index < len(row) {
+					s = row[f.Index]
+				}
+				val := f.formatValue(s)
+				if i > 0 && isLuaDefaultValue(f.Type, val) {
+					defaults[i] = true
+					val = ""
+				}
+				r.vals[i] = val
+			} else {
+				val := l.captureData(func() { l.formatData(f, row, 0) })
+				if strings.HasPrefix(val, "{") {
+					tables[val]++
+				}
+				r.vals[i] = val
+			}
+		}
+		rows = append(rows, r)
+	}
+
+	// 共享子表
+	shared := make(map[string]int)
+	for _, r := range rows {
+		for _, val := range r.vals {
+			if tables[val] > 1 && shared[val] == 0 {
+				if len(shared) == 0 {
+					l.appendData("local _S = {}\n")
+				}
+				shared[val] = len(shared) + 1
+				l.appendData(fmt.Sprintf("_S[%d] = %s\n", shared[val], val))
+			}
+		}
+	}
+	if len(shared) > 0 {
+		l.appendData("\n")
+	}
+
+	// 默认值
+	hasDefault := false
+	for i, f := range fields {
+		if defaults[i] {
+			if !hasDefault {
+				hasDefault = true
+				l.appendData("local _D = {\n")
+			}
+			l.appendData(fmt.Sprintf("%s%s = %s", getIndent(1), f.Name, f.defaultValue()))
+			l.appendData(",\n")
+		}
+	}
+	if hasDefault {
+		l.replaceTail("\n}\n\n")
+	}
+
+	l.appendData(l.formatLuaComment(l.mode))
+	l.appendData("\nlocal t = {\n")
+	for _, r := range rows {
+		l.line = r.line
+		l.appendIndent(1)
+		l.appendData("[")
+		l.appendData(r.row[0])
+		l.appendData("]")
+		l.appendSpace()
+		l.appendData("=")
+		l.appendSpace()
+		l.appendData("{")
+		l.appendEOL()
+		for i, f := range fields {
+			val := r.vals[i]
+			if len(val) == 0 {
+				continue
+			}
+			l.appendIndent(2)
+			l.appendData(f.Name)
+			l.appendSpace()
+			l.appendData("=")
+			l.appendSpace()
+			if n, ok := shared[val]; ok {
+				l.appendData(fmt.Sprintf("_S[%d]", n))
+			} else if f.isBuiltin() {
+				l.appendData(val)
+			} else {
+				l.formatData(f, r.row, 2)
+			}
+			l.appendComma()
+		}
+		l.replaceComma()
+		l.appendIndent(1)
+		l.appendData("}")
+		l.appendData(",\n")
+	}
+	if len(rows) > 0 {
 		l.replaceTail("\n")
+	}
+	l.appendData("}")
+
+	if hasDefault {
+		l.appendData("\n\nlocal _mt = {__index = _D}\n")
+		l.appendData("for _, row in pairs(t) do\n")
+		l.appendData(getIndent(1) + "setmetatable(row, _mt)\n")
+		l.appendData("end")
+	}
+	l.appendData("\nreturn t")
+}
+
+// 是否使用列布局导出(只对横向表有效)
+func (l *LuaFormater) isLuaColumns() bool {
+	if l.Vertical {
+		return false
+	}
+	for _, name := range GFlags.LuaCols {
+		if name == "*" || name == l.OutName {
+			return true
+		}
+	}
+	return false
+}
+
+// 列布局导出(横向表)
+// 字段名列表 _F 加上按位置存储的行数组 _R，加载时只需构造数组，
+// 行通过元表按字段名访问对应位置的值，不需要重建为键值表
+func (l *LuaFormater) formatColumnRows() {
+	fields := make([]*Field, 0, len(l.RootField.Vals))
+	names := make([]string, 0, len(l.RootField.Vals))
+	for _, f := range l.RootField.Vals {
+		if f.isHitMode(l.mode) {
+			fields = append(fields, f)
+			names = append(names, formatString(f.Name))
+		}
+	}
+
+	l.appendData("local _F = {")
+	l.appendData(strings.Join(names, ", "))
+	l.appendData("}\n")
+
+	cnt := 0
+	l.appendData("local _R = {\n")
+	for _, row := range l.Rows {
+		l.line++
+		key := row[0]
+		if strings.HasPrefix(key, "//") || key == "" {
+			continue
+		}
+		if !l.isRowHit(row, l.mode) {
+			continue
+		}
+		cnt++
+		l.appendIndent(1)
+		l.appendData("{")
+		for i, f := range fields {
+			if i > 0 {
+				l.appendData(",")
+				l.appendSpace()
+			}
+			l.formatData(f, row, 1)
+		}
 		l.appendData("}")
+		l.appendData(",\n")
+	}
+	if cnt > 0 {
+		l.replaceTail("\n")
 	}
+	l.appendData("}\n\n")
+
+	l.appendData(l.formatLuaComment(l.mode))
+	l.appendData("\nlocal t = {}\n")
+	l.appendData(`local _K = {}
+for i, name in ipairs(_F) do
+  _K[name] = i
+end
+local _mt = {__index = function(row, k)
+  local i = _K[k]
+  if i then
+    return rawget(row, i)
+  end
+end}
+for _, row in ipairs(_R) do
+  t[row[1]] = setmetatable(row, _mt)
+end`)
 	l.appendData("\nreturn t")
 }
 
+// 值是否等于类型默认值
+func isLuaDefaultValue(t *Type, val string) bool {
+	if val == t.defaultValue() {
+		return true
+	}
+	if t.isNumber() {
+		f, err := strconv.ParseFloat(val, 64)
+		return err == nil && f == 0
+	}
+	return false
+}
+
+// 捕获 fn 生成的数据(不写入 Datas)
+func (l *LuaFormater) captureData(fn func()) string {
+	start := len(l.Datas)
+	fn()
+	s := strings.Join(l.Datas[start:], "")
+	l.Datas = l.Datas[:start]
+	return s
+}
+
 func (l *LuaFormater) formatData(field *Field, row []string, depth int) {
+	if field.isInline() {
+		l.formatInline(field.Type, field.inlineOf(row), depth)
+		return
+	}
+
 	fkind := field.Kind
 	switch fkind {
 	case TArray:
 		l.appendData("{")
 		l.appendEOL()
-		for i, f := range field.Vals {
+		for i, f := range field.arrayVals(row) {
 			l.appendIndent(depth + 1)
-			if l.Vertical || !GFlags.Compact {
+			if l.Vertical || !l.Compact {
 				l.appendData("[")
 				l.appendData(strconv.Itoa(i + 1))
 				l.appendData("]")
@@ -136,6 +396,69 @@ func (l *LuaFormater) formatData(field *Field, row []string, depth int) {
 	}
 }
 
+// 单元格内联值
+func (l *LuaFormater) formatInline(t *Type, v *inlineValue, depth int) {
+	switch t.Kind {
+	case TArray:
+		l.appendData("{")
+		l.appendEOL()
+		for i, e := range v.Vals {
+			l.appendIndent(depth + 1)
+			if l.Vertical || !l.Compact {
+				l.appendData("[")
+				l.appendData(strconv.Itoa(i + 1))
+				l.appendData("]")
+				l.appendSpace()
+				l.appendData("=")
+				l.appendSpace()
+			}
+			l.formatInline(t.Vtype, e, depth+1)
+			l.appendComma()
+		}
+		l.replaceComma()
+		l.appendIndent(depth)
+		l.appendData("}")
+	case TMap:
+		l.appendData("{")
+		l.appendEOL()
+		for i, k := range v.Keys {
+			l.appendIndent(depth + 1)
+			if t.Ktype.isNumber() {
+				l.appendData("[")
+				l.appendData(k.Val)
+				l.appendData("]")
+			} else {
+				l.appendData(k.Val)
+			}
+			l.appendSpace()
+			l.appendData("=")
+			l.appendSpace()
+			l.formatInline(t.Vtype, v.Vals[i], depth+1)
+			l.appendComma()
+		}
+		l.replaceComma()
+		l.appendIndent(depth)
+		l.appendData("}")
+	case TStruct:
+		l.appendData("{")
+		l.appendEOL()
+		for i, name := range t.Fnames {
+			l.appendIndent(depth + 1)
+			l.appendData(name)
+			l.appendSpace()
+			l.appendData("=")
+			l.appendSpace()
+			l.formatInline(t.Ftypes[name], v.Vals[i], depth+1)
+			l.appendComma()
+		}
+		l.replaceComma()
+		l.appendIndent(depth)
+		l.appendData("}")
+	default:
+		l.appendData(t.formatValue(v.Val))
+	}
+}
+
 // json
 func (l *LuaFormater) formatJsonKey(key any) string {
 	var keystr string
@@ -191,7 +514,7 @@ func (l *LuaFormater) formatJsonValue(field *Field, t *Type, obj any, depth int)
 		l.appendEOL()
 		for i, v := range val {
 			l.appendIndent(depth + 1)
-			if l.Vertical || !GFlags.Compact {
+			if l.Vertical || !l.Compact {
 				l.appendData(l.formatJsonKey(i + 1))
 				l.appendSpace()
 				l.appendData("=")
@@ -212,3 +535,261 @@ func (l *LuaFormater) formatJsonValue(field *Field, t *Type, obj any, depth int)
 		l.appendData(fmt.Sprintf("%v", val))
 	}
 }
+
+//#region MARK: EmmyLua 注解
+
+// EmmyLua 注解生成器
+// 嵌套结构体(多列结构体、单元格结构体、json 结构体)生成独立的 ---@class，类名与 C# 类名一致
+// 结构体别名(struct#Alias)的类生成到公共注解文件 types.lua，配置表文件中只引用类名
+type luaAnnotator struct {
+	mode    string
+	classes []string
+	aliases map[string]string // 结构体别名类(类名 -> 类注解)
+	seen    map[string]bool
+}
+
+func newLuaAnnotator(mode string) *luaAnnotator {
+	return &luaAnnotator{mode: mode, aliases: make(map[string]string), seen: make(map[string]bool)}
+}
+
+// 生成结构体别名类(包括其中的嵌套结构体类)，不写入配置表文件
+func (a *luaAnnotator) aliasClass(clsName string, build func(b *luaAnnotator)) {
+	if a.seen[clsName] {
+		return
+	}
+	a.seen[clsName] = true
+	b := newLuaAnnotator(a.mode)
+	build(b)
+	for _, cls := range b.classes {
+		a.aliases[luaClassName(cls)] = cls
+	}
+	for name, cls := range b.aliases {
+		a.aliases[name] = cls
+		a.seen[name] = true
+	}
+}
+
+// 字段类型名(多列复合类型按子字段列生成)
+func (a *luaAnnotator) fieldTypeName(field *Field, parentClsName, fieldName string) string {
+	if field.isInline() || len(field.Vals) == 0 || (field.Vtype != nil && field.Vtype.isAny()) {
+		return a.typeName(field.Type, parentClsName, fieldName)
+	}
+
+	switch field.Kind {
+	case TArray:
+		return a.fieldTypeName(field.Vals[0], parentClsName, fieldName) + "[]"
+	case TMap:
+		return "table<" + a.typeName(field.Ktype, parentClsName, fieldName) + "," + a.fieldTypeName(field.Vals[0], parentClsName, fieldName) + ">"
+	case TStruct:
+		clsName := structClassName(field.Type, parentClsName, fieldName)
+		if field.isShared() || a.seen[clsName] {
+			return clsName
+		}
+		if len(field.Aname) > 0 {
+			a.aliasClass(clsName, func(b *luaAnnotator) { b.fieldClass(field, clsName) })
+			return clsName
+		}
+		a.fieldClass(field, clsName)
+		return clsName
+	}
+	return a.typeName(field.Type, parentClsName, fieldName)
+}
+
+// 按子字段列生成结构体类注解
+func (a *luaAnnotator) fieldClass(field *Field, clsName string) {
+	a.seen[clsName] = true
+	idx := len(a.classes)
+	a.classes = append(a.classes, "")
+	lines := []string{"---@class " + clsName}
+	for _, sf := range field.Vals {
+		if sf.isHitMode(a.mode) {
+			lines = append(lines, luaFieldComment(sf.Name, a.fieldTypeName(sf, clsName, sf.Name), sf.Desc, sf.Comment))
+		}
+	}
+	a.classes[idx] = strings.Join(lines, "\n")
+}
+
+// 类型名(结构体按 Fnames 生成)
+func (a *luaAnnotator) typeName(t *Type, parentClsName, fieldName string) string {
+	switch t.Kind {
+	case TInt, TUint:
+		return "integer"
+	case TFloat:
+		return "number"
+	case TBool:
+		return "boolean"
+	case TString:
+		return "string"
+	case TArray:
+		return a.typeName(t.Vtype, parentClsName, fieldName) + "[]"
+	case TMap:
+		return "table<" + a.typeName(t.Ktype, parentClsName, fieldName) + "," + a.typeName(t.Vtype, parentClsName, fieldName) + ">"
+	case TStruct:
+		if len(t.Fnames) == 0 {
+			return "table"
+		}
+		clsName := structClassName(t, parentClsName, fieldName)
+		if t.isShared() {
+			return clsName
+		}
+		if len(t.Aname) > 0 {
+			a.aliasClass(clsName, func(b *luaAnnotator) { b.structClass(t, clsName, "", nil) })
+			return clsName
+		}
+		a.structClass(t, clsName, "", nil)
+		return clsName
+	case TJson:
+		if t.Vtype != nil {
+			return a.typeName(t.Vtype, parentClsName, fieldName)
+		}
+		return "table"
+	}
+	return "any"
+}
+
+// 按 Fnames 生成结构体类注解
+func (a *luaAnnotator) structClass(t *Type, clsName, desc string, fdescs map[string]string) {
+	if a.seen[clsName] {
+		return
+	}
+	a.seen[clsName] = true
+
+	idx := len(a.classes)
+	a.classes = append(a.classes, "")
+	lines := make([]string, 0, len(t.Fnames)+2)
+	if len(desc) > 0 {
+		lines = append(lines, "---"+desc)
+	}
+	lines = append(lines, "---@class "+clsName)
+	for _, fname := range t.Fnames {
+		lines = append(lines, luaFieldComment(fname, a.typeName(t.Ftypes[fname], clsName, fname), fdescs[fname], ""))
+	}
+	a.classes[idx] = strings.Join(lines, "\n")
+}
+
+func luaFieldComment(name, typeName, desc, comment string) string {
+	field := "---@field " + name + " " + typeName
+	if len(desc) > 0 {
+		field += " " + desc
+	}
+	if len(comment) > 0 {
+		field += "  (" + comment + ")"
+	}
+	return field
+}
+
+// 本次导出的结构体别名类(输出目录 -> 类名 -> 类注解)，导出完成后写入 types.lua
+var (
+	luaAliasMutex   sync.Mutex
+	luaAliasClasses = make(map[string]map[string]*luaAlias)
+)
+
+type luaAlias struct {
+	text   string // 类注解
+	source string // 首次定义的配置表
+}
+
+const luaAliasMark = "-- 配置表结构体别名(struct#Alias)"
+
+func resetLuaAliasClasses() {
+	luaAliasMutex.Lock()
+	defer luaAliasMutex.Unlock()
+	clear(luaAliasClasses)
+}
+
+// 登记配置表的结构体别名类，同一输出目录中同名的类定义必须一致
+func (x *Xlsx) addLuaAliasClasses(outdir string, aliases map[string]string) {
+	luaAliasMutex.Lock()
+	defer luaAliasMutex.Unlock()
+	classes := luaAliasClasses[outdir]
+	if classes == nil {
+		classes = make(map[string]*luaAlias)
+		luaAliasClasses[outdir] = classes
+	}
+	for _, name := range slices.Sorted(maps.Keys(aliases)) {
+		if c, ok := classes[name]; ok {
+			if c.text != aliases[name] && c.source != x.Name {
+				x.sprintfError("结构体别名%s的字段与配置表%s中的定义不一致", name, c.source)
+			}
+			continue
+		}
+		classes[name] = &luaAlias{text: aliases[name], source: x.Name}
+	}
+}
+
+// 读取 types.lua 中的结构体别名类(类名 -> 类注解)
+func readLuaAliasClasses(path string) map[string]string {
+	classes := make(map[string]string)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return classes
+	}
+	_, text, ok := strings.Cut(string(data), luaAliasMark+"\n")
+	if !ok {
+		return classes
+	}
+	for _, cls := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if name := luaClassName(cls); len(name) > 0 {
+			classes[name] = cls
+		}
+	}
+	return classes
+}
+
+// 类注解中的类名
+func luaClassName(cls string) string {
+	for _, line := range strings.Split(cls, "\n") {
+		if name, ok := strings.CutPrefix(line, "---@class "); ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// WriteSharedTypesLua 在 outdir 下生成共享类型和结构体别名的 EmmyLua 注解文件 types.lua
+// 只更新本次导出的配置表中的别名类，其他类保留(--force 导出全部配置表时重建)
+func WriteSharedTypesLua(outdir string) {
+	luaAliasMutex.Lock()
+	collected := luaAliasClasses[outdir]
+	delete(luaAliasClasses, outdir)
+	luaAliasMutex.Unlock()
+
+	path := filepath.Join(outdir, "types.lua")
+	aliases := make(map[string]string)
+	if !GFlags.Force || len(GFlags.Files) > 0 {
+		aliases = readLuaAliasClasses(path)
+	}
+	for name, c := range collected {
+		aliases[name] = c.text
+	}
+	if len(SharedTypeNames) == 0 && len(aliases) == 0 {
+		return
+	}
+
+	a := newLuaAnnotator("")
+	for _, name := range SharedTypeNames {
+		st := SharedTypes[name]
+		a.structClass(st.Type, toTitle(st.Name), st.Desc, st.Fdescs)
+	}
+
+	lines := make([]string, 0, len(a.classes)+len(aliases)+3)
+	lines = append(lines, "-- Auto generated by excelparser. DO NOT EDIT!")
+	lines = append(lines, "---@meta")
+	for _, cls := range a.classes {
+		lines = append(lines, "", cls)
+	}
+	if len(aliases) > 0 {
+		lines = append(lines, "", luaAliasMark)
+		for i, name := range slices.Sorted(maps.Keys(aliases)) {
+			lines = append(lines, ternary(i == 0, "", "\n")+aliases[name])
+		}
+	}
+	lines = append(lines, "")
+
+	if err := os.MkdirAll(outdir, 0o755); err != nil {
+		return
+	}
+	os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o666)
+}
+
+//#endregion
@@ -0,0 +1,150 @@
+// 导出文件清单
+
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// 清单文件名
+const ManifestName = "manifest.json"
+
+// 导出文件清单，用于热更新比较版本和客户端下载后校验文件完整性
+type Manifest struct {
+	Version  int                      `json:"version"`            // 清单版本(有文件变化时加一)
+	Compress string                   `json:"compress,omitempty"` // 压缩算法
+	Encrypt  bool                     `json:"encrypt,omitempty"`  // 是否加密
+	Files    map[string]*ManifestFile `json:"files"`              // 文件名 -> 文件信息
+}
+
+type ManifestFile struct {
+	Size    int64  `json:"size"`             // 文件大小
+	Sha256  string `json:"sha256"`           // 文件内容 sha256(磁盘上的文件，即打包后的数据)
+	Source  string `json:"source,omitempty"` // 来源工作簿
+	Version int    `json:"version"`          // 文件版本(内容变化时加一)
+}
+
+// 清单比较结果
+type ManifestChange struct {
+	Status string // A=新增, M=修改, D=删除
+	Name   string // 文件名
+	File   *ManifestFile
+}
+
+// 读取清单，path 可以是清单文件或者输出目录
+func ReadManifest(path string) (*Manifest, error) {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, ManifestName)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("清单文件[%s]格式错误: %v", path, err)
+	}
+	if m.Files == nil {
+		m.Files = make(map[string]*ManifestFile)
+	}
+	return m, nil
+}
+
+// WriteManifest 在 outdir 下生成导出文件的清单
+// 清单由磁盘上的导出文件生成，因此包含本次未修改(跳过导出)的配置表
+// 文件内容与上一次清单中的哈希一致时保留原版本号
+func WriteManifest(outdir, mode, format string) error {
+	type outputFile struct {
+		name   string
+		source string
+	}
+	files := make([]outputFile, 0, len(XlsxList)+1)
+	if ext := outputExt(format); len(ext) > 0 {
+		for _, x := range XlsxList {
+			source, err := filepath.Rel(GFlags.Path, x.PathName)
+			if err != nil {
+				source = filepath.Base(x.PathName)
+			}
+			files = append(files, outputFile{fmt.Sprintf("%s.%s", x.OutName, ext), filepath.ToSlash(source)})
+		}
+		if len(GFlags.Bundle) > 0 && len(bundleExt(format)) > 0 {
+			files = append(files, outputFile{fmt.Sprintf("%s.%s", GFlags.Bundle, ext), ""})
+		}
+	}
+	if format == "sqlite" {
+		files = append(files, outputFile{mode + ".db", ""})
+	}
+
+	old, err := ReadManifest(outdir)
+	if err != nil {
+		old = &Manifest{Files: make(map[string]*ManifestFile)}
+	}
+	m := &Manifest{Version: old.Version, Files: make(map[string]*ManifestFile)}
+	if format != "sqlite" {
+		// sqlite 数据库不打包
+		m.Compress = GFlags.Compress
+		m.Encrypt = len(packKey) > 0
+	}
+	changed := len(old.Files) == 0
+	for _, f := range files {
+		data, err := os.ReadFile(outdir + f.name)
+		if err != nil {
+			continue
+		}
+		sum := sha256.Sum256(data)
+		mf := &ManifestFile{Size: int64(len(data)), Sha256: hex.EncodeToString(sum[:]), Source: f.source, Version: 1}
+		if o, ok := old.Files[f.name]; ok {
+			mf.Version = o.Version
+			if o.Sha256 != mf.Sha256 {
+				mf.Version++
+				changed = true
+			}
+		} else {
+			changed = true
+		}
+		m.Files[f.name] = mf
+	}
+	if len(m.Files) == 0 {
+		return nil
+	}
+	if len(m.Files) != len(old.Files) {
+		// 有文件被删除
+		changed = true
+	}
+	if changed {
+		m.Version++
+	}
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(outdir, ManifestName), append(data, '\n'), 0o666)
+}
+
+// DiffManifest 比较两个清单，返回需要更新(新增、修改)和删除的文件，按文件名排序
+func DiffManifest(old, cur *Manifest) []ManifestChange {
+	changes := make([]ManifestChange, 0)
+	for name, f := range cur.Files {
+		if o, ok := old.Files[name]; !ok {
+			changes = append(changes, ManifestChange{"A", name, f})
+		} else if o.Sha256 != f.Sha256 || o.Size != f.Size || o.Version != f.Version {
+			changes = append(changes, ManifestChange{"M", name, f})
+		}
+	}
+	for name, o := range old.Files {
+		if _, ok := cur.Files[name]; !ok {
+			changes = append(changes, ManifestChange{"D", name, o})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
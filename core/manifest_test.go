@@ -0,0 +1,135 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteManifest(t *testing.T) {
+	flags, list := GFlags, XlsxList
+	t.Cleanup(func() { GFlags, XlsxList = flags, list })
+	setPackOptions(t, CompressNone, nil)
+
+	GFlags.Path = filepath.Join(t.TempDir(), "xlsx")
+	GFlags.Bundle = ""
+	GFlags.Compress = ""
+	XlsxList = []*Xlsx{
+		{OutName: "item", PathName: filepath.Join(GFlags.Path, "D-道具@item.xlsx")},
+		{OutName: "shop", PathName: filepath.Join(GFlags.Path, "sub", "S-商店@shop.xlsx")},
+	}
+	outdir := t.TempDir() + "/"
+	write := func(name, data string) {
+		t.Helper()
+		if err := os.WriteFile(outdir+name, []byte(data), 0o666); err != nil {
+			t.Fatal(err)
+		}
+	}
+	manifest := func() *Manifest {
+		t.Helper()
+		if err := WriteManifest(outdir, "server", "lua"); err != nil {
+			t.Fatal(err)
+		}
+		m, err := ReadManifest(outdir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m
+	}
+	diff := func(old, cur *Manifest) string {
+		var s []string
+		for _, c := range DiffManifest(old, cur) {
+			s = append(s, c.Status+" "+c.Name)
+		}
+		return strings.Join(s, ",")
+	}
+
+	write("item.lua", "return {}")
+	write("shop.lua", "return {1}")
+	m1 := manifest()
+	if m1.Version != 1 || len(m1.Files) != 2 {
+		t.Fatalf("manifest = %+v", m1)
+	}
+	sum := sha256.Sum256([]byte("return {}"))
+	if item := m1.Files["item.lua"]; item.Size != 9 || item.Sha256 != hex.EncodeToString(sum[:]) || item.Version != 1 {
+		t.Errorf("item.lua = %+v", item)
+	}
+	if src := m1.Files["shop.lua"].Source; src != "sub/S-商店@shop.xlsx" {
+		t.Errorf("shop.lua source = %s", src)
+	}
+
+	// 内容不变时版本号不变
+	m2 := manifest()
+	if m2.Version != 1 || m2.Files["item.lua"].Version != 1 || len(DiffManifest(m1, m2)) != 0 {
+		t.Errorf("未修改的清单 = %+v, diff %s", m2, diff(m1, m2))
+	}
+
+	// 修改、删除、新增文件
+	write("item.lua", "return {2}")
+	os.Remove(outdir + "shop.lua")
+	XlsxList = append(XlsxList, &Xlsx{OutName: "task", PathName: filepath.Join(GFlags.Path, "T-任务@task.xlsx")})
+	write("task.lua", "return {}")
+	m3 := manifest()
+	if m3.Version != 2 || m3.Files["item.lua"].Version != 2 || m3.Files["task.lua"].Version != 1 {
+		t.Errorf("修改后的清单 = %+v", m3)
+	}
+	if got := diff(m1, m3); got != "M item.lua,D shop.lua,A task.lua" {
+		t.Errorf("diff = %s", got)
+	}
+
+	// 只删除文件时清单版本号也要加一
+	os.Remove(outdir + "task.lua")
+	m4 := manifest()
+	if m4.Version != 3 || diff(m3, m4) != "D task.lua" {
+		t.Errorf("删除后的清单 = %+v", m4)
+	}
+
+	// 打包后的文件按磁盘上的数据计算哈希，重新加密的文件版本号加一
+	packCompress, packKey = CompressGzip, make([]byte, 16)
+	GFlags.Compress = "gzip"
+	packed, err := packData([]byte("return {2}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	write("item.lua", string(packed))
+	m5 := manifest()
+	sum = sha256.Sum256(packed)
+	if item := m5.Files["item.lua"]; !m5.Encrypt || m5.Compress != "gzip" || item.Sha256 != hex.EncodeToString(sum[:]) || item.Version != 3 {
+		t.Errorf("打包后的清单 = %+v, item.lua %+v", m5, item)
+	}
+	if got := diff(m4, m5); got != "M item.lua" {
+		t.Errorf("diff = %s", got)
+	}
+}
+
+func TestDiffManifest(t *testing.T) {
+	old := &Manifest{Files: map[string]*ManifestFile{
+		"a.lua": {Size: 1, Sha256: "aa", Version: 1},
+		"b.lua": {Size: 1, Sha256: "bb", Version: 1},
+		"c.lua": {Size: 1, Sha256: "cc", Version: 1},
+		"d.lua": {Size: 1, Sha256: "dd", Version: 1},
+	}}
+	cur := &Manifest{Files: map[string]*ManifestFile{
+		"a.lua": {Size: 1, Sha256: "aa", Version: 1},
+		"b.lua": {Size: 1, Sha256: "b2", Version: 2},
+		"c.lua": {Size: 2, Sha256: "cc", Version: 1},
+		"d.lua": {Size: 1, Sha256: "dd", Version: 2},
+		"e.lua": {Size: 1, Sha256: "ee", Version: 1},
+	}}
+	want := []string{"M b.lua", "M c.lua", "M d.lua", "A e.lua"}
+	changes := DiffManifest(old, cur)
+	if len(changes) != len(want) {
+		t.Fatalf("changes = %v", changes)
+	}
+	for i, c := range changes {
+		if got := c.Status + " " + c.Name; got != want[i] || c.File != cur.Files[c.Name] {
+			t.Errorf("changes[%d] = %s, want %s", i, got, want[i])
+		}
+	}
+	if changes := DiffManifest(cur, old); len(changes) != 4 || changes[3].Status != "D" || changes[3].Name != "e.lua" {
+		t.Errorf("反向比较 = %v", changes)
+	}
+}
@@ -0,0 +1,402 @@
+// 配置表三方合并
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// 合并冲突
+type MergeConflict struct {
+	Id     string `json:"id"`     // 行 id(纵向表为空)
+	Field  string `json:"field"`  // 字段路径(行冲突为空)
+	Cell   string `json:"cell"`   // 合并结果中的单元格(行冲突为 id 单元格)
+	Reason string `json:"reason"` // 冲突原因
+	Base   string `json:"base"`   // 原始值
+	Ours   string `json:"ours"`   // 本地值
+	Theirs string `json:"theirs"` // 远端值
+}
+
+// 合并结果
+type MergeResult struct {
+	Updated   int             // 合并的单元格数
+	Added     int             // 新增的行数
+	Deleted   int             // 删除的行数
+	Conflicts []MergeConflict // 冲突列表
+}
+
+// 合并输入(某个版本的配置表)
+type mergeSide struct {
+	x       *Xlsx
+	file    *excelize.File
+	lines   [][]string      // 原始单元格值(纵向表为列)
+	records map[string]int  // id -> lines 下标
+	ids     []string        // id 列表(按行顺序)
+	columns []*headerColumn // 表头列
+	cols    map[string]int  // 字段路径 -> 列下标
+	header  [][]string      // 表头
+}
+
+func openMergeSide(path string) (*mergeSide, error) {
+	x, err := LoadXlsx(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &mergeSide{x: x, file: f, records: make(map[string]int), cols: make(map[string]int)}
+
+	opts := excelize.Options{RawCellValue: true}
+	if x.Vertical {
+		s.lines, err = f.GetCols(x.SheetName, opts)
+	} else {
+		s.lines, err = f.GetRows(x.SheetName, opts)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	for i := range s.lines {
+		s.lines[i] = trimLine(s.lines[i])
+	}
+	s.header = s.lines[:min(HeadLineNum, len(s.lines))]
+	for i := HeadLineNum; i < len(s.lines); i++ {
+		id := ""
+		if !x.Vertical {
+			id = strings.TrimSpace(cellOf(s.lines[i], 0))
+			if id == "" || strings.HasPrefix(id, "//") {
+				continue
+			}
+		}
+		if _, ok := s.records[id]; !ok {
+			s.records[id] = i
+			s.ids = append(s.ids, id)
+		}
+		if x.Vertical {
+			break
+		}
+	}
+	s.columns = x.headerColumns()
+	for _, c := range s.columns {
+		s.cols[c.Path] = c.Index
+	}
+	return s, nil
+}
+
+func (s *mergeSide) close() {
+	s.file.Close()
+}
+
+// 单元格值
+func (s *mergeSide) cell(id string, col int) string {
+	li, ok := s.records[id]
+	if !ok {
+		return ""
+	}
+	return cellOf(s.lines[li], col)
+}
+
+// 单元格地址
+func (s *mergeSide) axis(line, col int) string {
+	var name string
+	if s.x.Vertical {
+		name, _ = excelize.CoordinatesToCellName(line+1, col+1)
+	} else {
+		name, _ = excelize.CoordinatesToCellName(col+1, line+1)
+	}
+	return name
+}
+
+func cellOf(line []string, i int) string {
+	if i < len(line) {
+		return line[i]
+	}
+	return ""
+}
+
+// 去掉行尾的空单元格
+func trimLine(line []string) []string {
+	n := len(line)
+	for n > 0 && len(line[n-1]) == 0 {
+		n--
+	}
+	return line[:n]
+}
+
+func headerEqual(a, b [][]string) bool {
+	return slices.EqualFunc(a, b, func(x, y []string) bool { return slices.Equal(x, y) })
+}
+
+// MergeXlsx 按 id 和字段三方合并配置表，结果写入 out
+// 表头只有一方修改时以该方为合并目标，否则以本地版本为目标；双方都修改了表头且不一致时无法合并
+// 冲突的单元格保留目标版本的值，并添加批注说明三方的值
+func MergeXlsx(basePath, oursPath, theirsPath, out string) (*MergeResult, error) {
+	sides := make([]*mergeSide, 0, 3)
+	defer func() {
+		for _, s := range sides {
+			s.close()
+		}
+	}()
+	for _, path := range []string{basePath, oursPath, theirsPath} {
+		s, err := openMergeSide(path)
+		if err != nil {
+			return nil, err
+		}
+		sides = append(sides, s)
+	}
+	base, ours, theirs := sides[0], sides[1], sides[2]
+	if base.x.Vertical != ours.x.Vertical || base.x.Vertical != theirs.x.Vertical {
+		return nil, errors.New("横向表和纵向表不能合并")
+	}
+
+	// 选择合并目标
+	target, other := ours, theirs
+	oursChanged := !headerEqual(base.header, ours.header)
+	theirsChanged := !headerEqual(base.header, theirs.header)
+	if oursChanged && theirsChanged && !headerEqual(ours.header, theirs.header) {
+		return nil, errors.New("双方都修改了表头，无法自动合并")
+	}
+	if theirsChanged && !oursChanged {
+		target, other = theirs, ours
+	}
+
+	m := &merger{base: base, target: target, other: other, targetIsOurs: target == ours, result: &MergeResult{}}
+	m.merge()
+
+	if err := target.file.SaveAs(out); err != nil {
+		return nil, err
+	}
+	return m.result, nil
+}
+
+type merger struct {
+	base, target, other *mergeSide
+	targetIsOurs        bool
+	result              *MergeResult
+	deletes             []int // 需要删除的目标行(lines 下标)
+	pending             []pendingConflict
+}
+
+// 待标注的冲突(删除行之后再添加批注)
+type pendingConflict struct {
+	index     int // Conflicts 下标
+	line, col int // 目标单元格(lines 下标)
+}
+
+func (m *merger) merge() {
+	t, o, b := m.target, m.other, m.base
+	for _, id := range t.ids {
+		_, inBase := b.records[id]
+		_, inOther := o.records[id]
+		switch {
+		case inOther:
+			m.mergeRecord(id, inBase)
+		case inBase:
+			// 另一方删除了该行
+			if m.recordChanged(t, id) {
+				m.rowConflict(id, "一方删除了该行，另一方修改了该行")
+			} else {
+				m.deletes = append(m.deletes, t.records[id])
+			}
+		}
+	}
+
+	next := len(t.lines)
+	for _, id := range o.ids {
+		if _, ok := t.records[id]; ok {
+			continue
+		}
+		if _, inBase := b.records[id]; inBase {
+			// 目标删除了该行
+			if m.recordChanged(o, id) {
+				m.rowConflict(id, "一方删除了该行，另一方修改了该行")
+			}
+			continue
+		}
+		m.appendRecord(id, next)
+		next++
+	}
+
+	// 从后往前删除，避免行号变化
+	slices.Sort(m.deletes)
+	for i := len(m.deletes) - 1; i >= 0; i-- {
+		li := m.deletes[i]
+		if t.x.Vertical {
+			col, _ := excelize.ColumnNumberToName(li + 1)
+			t.file.RemoveCol(t.x.SheetName, col)
+		} else {
+			t.file.RemoveRow(t.x.SheetName, li+1)
+		}
+		m.result.Deleted++
+	}
+
+	for _, p := range m.pending {
+		line := p.line
+		for _, li := range m.deletes {
+			if li < p.line {
+				line--
+			}
+		}
+		c := &m.result.Conflicts[p.index]
+		c.Cell = t.axis(line, p.col)
+		m.comment(c)
+	}
+}
+
+// 行是否相对原始版本有修改
+func (m *merger) recordChanged(s *mergeSide, id string) bool {
+	for _, c := range s.columns {
+		bv := ""
+		if bc, ok := m.base.cols[c.Path]; ok {
+			bv = m.base.cell(id, bc)
+		}
+		if s.cell(id, c.Index) != bv {
+			return true
+		}
+	}
+	return false
+}
+
+// 合并双方都存在的行
+func (m *merger) mergeRecord(id string, inBase bool) {
+	t, o, b := m.target, m.other, m.base
+	li := t.records[id]
+	for _, c := range t.columns {
+		col, path := c.Index, c.Path
+		tv := t.cell(id, col)
+		oc, inOther := o.cols[path]
+		if !inOther {
+			continue
+		}
+		ov := o.cell(id, oc)
+		bv := ""
+		if bc, ok := b.cols[path]; ok && inBase {
+			bv = b.cell(id, bc)
+		}
+		switch {
+		case tv == ov || ov == bv:
+			// 相同或另一方未修改
+		case tv == bv:
+			m.copyCell(o, o.records[id], oc, li, col)
+			m.result.Updated++
+		default:
+			m.cellConflict(id, path, li, col, bv, tv, ov)
+		}
+	}
+
+	// 目标中已删除的字段
+	for _, c := range o.columns {
+		if _, ok := t.cols[c.Path]; ok {
+			continue
+		}
+		bc, ok := b.cols[c.Path]
+		if ok && inBase && o.cell(id, c.Index) != b.cell(id, bc) {
+			m.cellConflict(id, c.Path, li, 0, b.cell(id, bc), "(字段已删除)", o.cell(id, c.Index))
+		}
+	}
+}
+
+// 添加另一方新增的行
+func (m *merger) appendRecord(id string, li int) {
+	t, o := m.target, m.other
+	sheet := t.x.SheetName
+	styleLine := len(t.lines) - 1
+	for _, c := range t.columns {
+		col, path := c.Index, c.Path
+		if styleLine >= HeadLineNum {
+			if style, err := t.file.GetCellStyle(sheet, t.axis(styleLine, col)); err == nil {
+				t.file.SetCellStyle(sheet, t.axis(li, col), t.axis(li, col), style)
+			}
+		}
+		if oc, ok := o.cols[path]; ok {
+			m.copyCell(o, o.records[id], oc, li, col)
+		}
+	}
+	m.result.Added++
+}
+
+// 复制单元格的值(保留数值、布尔类型和公式)
+func (m *merger) copyCell(src *mergeSide, srcLine, srcCol, dstLine, dstCol int) {
+	dst := m.target
+	srcAxis, dstAxis := src.axis(srcLine, srcCol), dst.axis(dstLine, dstCol)
+	sheet := dst.x.SheetName
+	if formula, _ := src.file.GetCellFormula(src.x.SheetName, srcAxis); len(formula) > 0 {
+		dst.file.SetCellFormula(sheet, dstAxis, formula)
+		return
+	}
+
+	val := cellOf(src.lines[srcLine], srcCol)
+	typ, _ := src.file.GetCellType(src.x.SheetName, srcAxis)
+	switch {
+	case len(val) == 0:
+		dst.file.SetCellValue(sheet, dstAxis, nil)
+	case typ == excelize.CellTypeBool:
+		dst.file.SetCellBool(sheet, dstAxis, val == "1" || val == "TRUE")
+	case typ == excelize.CellTypeNumber || typ == excelize.CellTypeUnset:
+		if i, err := strconv.ParseInt(val, 10, 64); err == nil {
+			dst.file.SetCellInt(sheet, dstAxis, i)
+		} else if f, err := strconv.ParseFloat(val, 64); err == nil {
+			dst.file.SetCellFloat(sheet, dstAxis, f, -1, 64)
+		} else {
+			dst.file.SetCellStr(sheet, dstAxis, val)
+		}
+	default:
+		dst.file.SetCellStr(sheet, dstAxis, val)
+	}
+}
+
+func (m *merger) cellConflict(id, path string, line, col int, base, tv, ov string) {
+	c := MergeConflict{Id: id, Field: path, Reason: "双方修改了同一个单元格", Base: base}
+	c.Ours, c.Theirs = ternary(m.targetIsOurs, tv, ov), ternary(m.targetIsOurs, ov, tv)
+	m.addConflict(c, line, col)
+}
+
+func (m *merger) rowConflict(id, reason string) {
+	line, ok := m.target.records[id]
+	if !ok {
+		// 目标中已删除的行没有对应的单元格
+		line = -1
+	}
+	m.addConflict(MergeConflict{Id: id, Reason: reason}, line, 0)
+}
+
+// 记录冲突，line 不小于 0 时在合并结果的单元格上添加批注
+func (m *merger) addConflict(c MergeConflict, line, col int) {
+	m.result.Conflicts = append(m.result.Conflicts, c)
+	if line >= 0 {
+		m.pending = append(m.pending, pendingConflict{len(m.result.Conflicts) - 1, line, col})
+	}
+}
+
+// 在冲突单元格上添加批注
+func (m *merger) comment(c *MergeConflict) {
+	text := "合并冲突: " + c.Reason
+	if len(c.Field) > 0 {
+		text += fmt.Sprintf("\n原始: %s\n本地: %s\n远端: %s", c.Base, c.Ours, c.Theirs)
+	}
+	m.target.file.AddComment(m.target.x.SheetName, excelize.Comment{
+		Cell:      c.Cell,
+		Author:    "excelparser",
+		Paragraph: []excelize.RichTextRun{{Text: text}},
+	})
+}
+
+// 冲突说明文本
+func (c *MergeConflict) String() string {
+	s := fmt.Sprintf("[%s]", c.Cell)
+	if len(c.Id) > 0 {
+		s += " id=" + c.Id
+	}
+	if len(c.Field) > 0 {
+		s += fmt.Sprintf(" %s: 原始=%q 本地=%q 远端=%q", c.Field, c.Base, c.Ours, c.Theirs)
+	}
+	return s + " " + c.Reason
+}
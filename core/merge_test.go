@@ -0,0 +1,119 @@
+package core
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestMergeXlsx(t *testing.T) {
+	dir := t.TempDir()
+	header := [][]string{
+		{"id", "name", "num"},
+		{"int", "string", "int"},
+		{"", "", ""},
+		{"道具 id", "名称", "数量"},
+	}
+	workbook := func(name string, rows ...[]string) string {
+		path := filepath.Join(dir, name, "D-道具@item.xlsx")
+		writeTestWorkbook(t, path, append(header[:4:4], rows...))
+		return path
+	}
+	base := workbook("base",
+		[]string{"1001", "a", "1"},
+		[]string{"1002", "b", "2"},
+		[]string{"1003", "c", "3"},
+		[]string{"1004", "d", "4"},
+	)
+	// 本地: 修改 1001.num、1002.name，删除 1003，新增 1005
+	ours := workbook("ours",
+		[]string{"1001", "a", "10"},
+		[]string{"1002", "bb", "2"},
+		[]string{"1004", "d", "4"},
+		[]string{"1005", "e", "5"},
+	)
+	// 远端: 修改 1001.name、1002.name、1003.num，删除 1004，新增 1006
+	theirs := workbook("theirs",
+		[]string{"1001", "aa", "1"},
+		[]string{"1002", "bx", "2"},
+		[]string{"1003", "c", "30"},
+		[]string{"1006", "f", "6"},
+	)
+	out := filepath.Join(dir, "D-道具@item.xlsx")
+	result, err := MergeXlsx(base, ours, theirs, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Updated != 1 || result.Added != 1 || result.Deleted != 1 {
+		t.Errorf("result = %+v", result)
+	}
+
+	var conflicts []string
+	for _, c := range result.Conflicts {
+		conflicts = append(conflicts, c.String())
+	}
+	want := []string{
+		`[B6] id=1002 name: 原始="b" 本地="bb" 远端="bx"`,
+		`id=1003`,
+	}
+	if len(conflicts) != len(want) {
+		t.Fatalf("conflicts = %q", conflicts)
+	}
+	for i, c := range conflicts {
+		if !strings.Contains(c, want[i]) {
+			t.Errorf("conflicts[%d] = %q, want %q", i, c, want[i])
+		}
+	}
+	if r := result.Conflicts[1].Reason; r != "一方删除了该行，另一方修改了该行" {
+		t.Errorf("row conflict reason = %s", r)
+	}
+
+	// 合并结果: 冲突的单元格保留本地值并添加批注
+	x, err := LoadXlsx(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rows []string
+	for _, row := range x.Rows {
+		rows = append(rows, strings.Join(row, " "))
+	}
+	if got := strings.Join(rows, ","); got != "1001 aa 10,1002 bb 2,1005 e 5,1006 f 6" {
+		t.Errorf("rows = %s", got)
+	}
+	f, err := excelize.OpenFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	comments, _ := f.GetComments("data")
+	if len(comments) != 1 || comments[0].Cell != "B6" {
+		t.Errorf("comments = %+v", comments)
+	}
+}
+
+func TestMergeXlsxHeaderConflict(t *testing.T) {
+	dir := t.TempDir()
+	workbook := func(name, desc string) string {
+		path := filepath.Join(dir, name, "D-道具@item.xlsx")
+		writeTestWorkbook(t, path, [][]string{
+			{"id", "name"},
+			{"int", "string"},
+			{"", ""},
+			{"道具 id", desc},
+			{"1001", "a"},
+		})
+		return path
+	}
+	base, ours, theirs := workbook("base", "名称"), workbook("ours", "名字"), workbook("theirs", "道具名")
+	if _, err := MergeXlsx(base, ours, theirs, filepath.Join(dir, "out.xlsx")); err == nil || !strings.Contains(err.Error(), "双方都修改了表头") {
+		t.Errorf("error = %v", err)
+	}
+
+	// 只有一方修改表头时以该方为合并目标
+	result, err := MergeXlsx(base, base, theirs, filepath.Join(dir, "out.xlsx"))
+	if err != nil || len(result.Conflicts) != 0 {
+		t.Errorf("result = %+v, %v", result, err)
+	}
+}
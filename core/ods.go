@@ -0,0 +1,267 @@
+// ods(OpenDocument 电子表格，LibreOffice、Google 表格导出)读取
+
+package core
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// OpenDocument 命名空间
+const (
+	odsOfficeNS = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	odsTableNS  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odsTextNS   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+)
+
+// ods 工作表，单元格的值为显示文本(text:p)
+// 不支持公式计算，公式单元格使用保存时的结果
+type odsSheet struct {
+	name     string
+	vertical bool
+	lines    [][]string
+	comments []CellComment
+	merges   []MergeRange
+}
+
+func (s *odsSheet) Name() string                      { return s.name }
+func (s *odsSheet) Vertical() bool                    { return s.vertical }
+func (s *odsSheet) Lines() ([][]string, error)        { return s.lines, nil }
+func (s *odsSheet) Comments() ([]CellComment, error)  { return s.comments, nil }
+func (s *odsSheet) MergeCells() ([]MergeRange, error) { return s.merges, nil }
+func (s *odsSheet) Close() error                      { return nil }
+
+func openOdsSheet(path string) (*odsSheet, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("ods文件打开失败: %v", err)
+	}
+	defer zr.Close()
+
+	for _, f := range zr.File {
+		if f.Name != "content.xml" {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("ods文件打开失败: %v", err)
+		}
+		defer r.Close()
+
+		names, sheets, err := readOdsContent(r)
+		if err != nil {
+			return nil, fmt.Errorf("ods文件格式错误: %v", err)
+		}
+		name, vertical := findDataSheetName(names)
+		if len(name) == 0 {
+			return nil, errNoDataSheet
+		}
+		s := sheets[name]
+		s.vertical = vertical
+		if vertical {
+			s.lines = transposeLines(s.lines)
+		}
+		return s, nil
+	}
+	return nil, errors.New("ods文件格式错误: 缺少 content.xml")
+}
+
+// ods 单元格读取状态
+type odsCell struct {
+	repeat    int             // 重复列数
+	colSpan   int             // 合并列数
+	rowSpan   int             // 合并行数
+	value     string          // office:value 等属性中的值(没有显示文本时使用)
+	isBool    bool            // 布尔值(使用 true/false，而不是显示文本 TRUE/FALSE)
+	text      strings.Builder // 显示文本
+	paras     int             // 段落数
+	comment   *strings.Builder
+	noteParas int
+}
+
+// 读取 content.xml 中的所有工作表名，只解析配置数据工作表(data/vdata)
+func readOdsContent(r io.Reader) ([]string, map[string]*odsSheet, error) {
+	names := make([]string, 0)
+	sheets := make(map[string]*odsSheet)
+
+	var (
+		cur        *odsSheet // 当前解析的工作表(nil 表示跳过)
+		row        int       // 当前行(从 0 开始)
+		col        int       // 当前列(从 0 开始)
+		rowRepeat  int       // 当前行重复次数
+		rowCells   []string  // 当前行的单元格
+		emptyCells int       // 未写入的空单元格数(只有后面有值时才写入)
+		emptyRows  int       // 未写入的空行数
+		cell       *odsCell  // 当前单元格
+		inNote     bool      // 在批注中
+		textDepth  int       // text:p/text:h 嵌套深度
+	)
+	attr := func(se xml.StartElement, space, local string) string {
+		for _, a := range se.Attr {
+			if a.Name.Space == space && a.Name.Local == local {
+				return a.Value
+			}
+		}
+		return ""
+	}
+	attrInt := func(se xml.StartElement, local string) int {
+		if n, err := strconv.Atoi(attr(se, odsTableNS, local)); err == nil && n > 0 {
+			return n
+		}
+		return 1
+	}
+	writeText := func(s string) {
+		if cell == nil || textDepth == 0 {
+			return
+		}
+		if inNote {
+			cell.comment.WriteString(s)
+		} else {
+			cell.text.WriteString(s)
+		}
+	}
+
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Space == odsTableNS && t.Name.Local == "table":
+				name := attr(t, odsTableNS, "name")
+				names = append(names, name)
+				cur = nil
+				if dataName, _ := findDataSheetName([]string{name}); len(dataName) > 0 {
+					cur = &odsSheet{name: name}
+					sheets[name] = cur
+				}
+				row, emptyRows = 0, 0
+			case cur == nil:
+			case t.Name.Space == odsTableNS && t.Name.Local == "table-row":
+				rowRepeat = attrInt(t, "number-rows-repeated")
+				rowCells, col, emptyCells = nil, 0, 0
+			case t.Name.Space == odsTableNS && (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell"):
+				cell = &odsCell{
+					repeat:  attrInt(t, "number-columns-repeated"),
+					colSpan: attrInt(t, "number-columns-spanned"),
+					rowSpan: attrInt(t, "number-rows-spanned"),
+				}
+				switch attr(t, odsOfficeNS, "value-type") {
+				case "float", "percentage", "currency":
+					cell.value = attr(t, odsOfficeNS, "value")
+				case "boolean":
+					cell.value = attr(t, odsOfficeNS, "boolean-value")
+					cell.isBool = true
+				case "date":
+					cell.value = attr(t, odsOfficeNS, "date-value")
+				case "time":
+					cell.value = attr(t, odsOfficeNS, "time-value")
+				case "string":
+					cell.value = attr(t, odsOfficeNS, "string-value")
+				}
+			case cell == nil:
+			case t.Name.Space == odsOfficeNS && t.Name.Local == "annotation":
+				inNote = true
+				if cell.comment == nil {
+					cell.comment = &strings.Builder{}
+				}
+			case t.Name.Space == odsTextNS && (t.Name.Local == "p" || t.Name.Local == "h"):
+				if textDepth == 0 {
+					// 多个段落用换行连接
+					if inNote {
+						if cell.noteParas > 0 {
+							cell.comment.WriteString("\n")
+						}
+						cell.noteParas++
+					} else {
+						if cell.paras > 0 {
+							cell.text.WriteString("\n")
+						}
+						cell.paras++
+					}
+				}
+				textDepth++
+			case t.Name.Space == odsTextNS && t.Name.Local == "s":
+				n, err := strconv.Atoi(attr(t, odsTextNS, "c"))
+				if err != nil || n < 1 {
+					n = 1
+				}
+				writeText(strings.Repeat(" ", n))
+			case t.Name.Space == odsTextNS && t.Name.Local == "tab":
+				writeText("\t")
+			case t.Name.Space == odsTextNS && t.Name.Local == "line-break":
+				writeText("\n")
+			}
+
+		case xml.CharData:
+			writeText(string(t))
+
+		case xml.EndElement:
+			switch {
+			case t.Name.Space == odsTableNS && t.Name.Local == "table":
+				cur = nil
+			case cur == nil:
+			case t.Name.Space == odsTextNS && (t.Name.Local == "p" || t.Name.Local == "h"):
+				textDepth--
+			case t.Name.Space == odsOfficeNS && t.Name.Local == "annotation":
+				inNote = false
+			case t.Name.Space == odsTableNS && (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell"):
+				value := cell.text.String()
+				if cell.paras == 0 || cell.isBool {
+					value = cell.value
+				}
+				if cell.comment != nil {
+					axis, _ := excelize.CoordinatesToCellName(col+1, row+1)
+					cur.comments = append(cur.comments, CellComment{axis, cell.comment.String()})
+				}
+				if t.Name.Local == "table-cell" && (cell.colSpan > 1 || cell.rowSpan > 1) {
+					start, _ := excelize.CoordinatesToCellName(col+1, row+1)
+					end, _ := excelize.CoordinatesToCellName(col+cell.colSpan, row+cell.rowSpan)
+					cur.merges = append(cur.merges, MergeRange{start, end})
+				}
+				if len(value) == 0 {
+					// 行尾的空单元格通常重复到最大列数，不写入
+					emptyCells += cell.repeat
+				} else {
+					for ; emptyCells > 0; emptyCells-- {
+						rowCells = append(rowCells, "")
+					}
+					for range cell.repeat {
+						rowCells = append(rowCells, value)
+					}
+				}
+				col += cell.repeat
+				cell = nil
+			case t.Name.Space == odsTableNS && t.Name.Local == "table-row":
+				if len(rowCells) == 0 {
+					// 表尾的空行通常重复到最大行数，不写入
+					emptyRows += rowRepeat
+				} else {
+					for ; emptyRows > 0; emptyRows-- {
+						cur.lines = append(cur.lines, nil)
+					}
+					for range rowRepeat {
+						cur.lines = append(cur.lines, slices.Clone(rowCells))
+					}
+				}
+				row += rowRepeat
+			}
+		}
+	}
+	return names, sheets, nil
+}
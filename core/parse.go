@@ -31,6 +31,7 @@ func WalkPath() error {
 	}
 
 	XlsxList = make([]*Xlsx, 0)
+	TypesXlsx = make([]string, 0)
 	OutNames := make(map[string]string) // 导出名冲突检查
 	err = filepath.Walk(xlsxPath, func(path string, f os.FileInfo, err error) error {
 		if f == nil {
@@ -41,13 +42,17 @@ func WalkPath() error {
 			return nil
 		}
 
-		ok, mErr := filepath.Match("[^~$]*.xlsx", f.Name())
-		if ok {
-			modifyTime := uint64(f.ModTime().UnixNano() / 1000000)
+		if isSheetFile(f.Name()) {
+			modifyTime := sheetModTime(path, f)
 			fname := strings.TrimPrefix(path, xlsxPath+string(filepath.Separator)) // eg.: tpl/D道具表@item.xlsx
 			dirname := strings.TrimSuffix(fname, f.Name())                         // eg.: tpl/
 			fileName := getFileName(f.Name())                                      // eg.: D道具表@item
 			outName := fileName                                                    // eg.: item
+			if isTypesXlsx(fileName) && strings.EqualFold(filepath.Ext(f.Name()), ".xlsx") {
+				// 共享类型定义工作簿不导出(只支持 xlsx，其他格式按普通配置表处理)
+				TypesXlsx = append(TypesXlsx, path)
+				return nil
+			}
 			if s := strings.SplitN(outName, "@", 2); len(s) > 1 {
 				outName = s[1]
 			}
@@ -71,7 +76,7 @@ func WalkPath() error {
 			MaxFileLen = max(MaxFileLen, len(task.FileName))
 			XlsxList = append(XlsxList, task)
 		}
-		return mErr
+		return nil
 	})
 
 	// 按 Name 排序 XlsxList
@@ -97,6 +102,49 @@ func FindXlsxByName(name string) *Xlsx {
 	return nil
 }
 
+// 解析单个配置表文件(不导出)，用于比较、合并等命令
+// 表头解析失败时返回错误，数据错误保存在 Errors 中
+func LoadXlsx(path string) (*Xlsx, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if SharedTypes == nil {
+		SharedTypes = make(map[string]*SharedType)
+	}
+
+	fileName := getFileName(path)
+	outName := fileName
+	if s := strings.SplitN(outName, "@", 2); len(s) > 1 {
+		outName = s[1]
+	}
+	x := &Xlsx{
+		Name:         filepath.Base(path),
+		PathName:     path,
+		FileName:     fileName,
+		DirName:      filepath.Dir(path),
+		OutName:      outName,
+		Errors:       make([]string, 0),
+		LastModified: sheetModTime(path, info),
+		Exports:      make([]ExportInfo, 0),
+	}
+
+	sheet, err := OpenSheet(path)
+	if err != nil {
+		return nil, fmt.Errorf("%s 打开失败: %v", x.Name, err)
+	}
+	defer func() {
+		x.Sheet = nil
+		sheet.Close()
+	}()
+
+	x.Sheet = sheet
+	if !x.parseExcel() {
+		return nil, fmt.Errorf("%s 解析失败: %s", x.Name, strings.Join(x.Errors, "; "))
+	}
+	return x, nil
+}
+
 func LoadExportTime() {
 	data, err := os.ReadFile(ExportYaml)
 	if err != nil {
@@ -139,6 +187,8 @@ func SaveExportTime() {
 func StartParse(xlsx *Xlsx) {
 	// 清空 Errors，以免上次的错误影响本次结果
 	xlsx.Errors = xlsx.Errors[:0]
+	xlsx.CellErrors = xlsx.CellErrors[:0]
+	xlsx.ErrorReport = ""
 	xlsx.Skipped = false
 	needParse := xlsx.GetNeedParse()
 	if len(needParse) == 0 {
@@ -191,6 +241,12 @@ func Run(handler *ParseHandler) error {
 	if err != nil {
 		return err
 	}
+	if err := LoadSharedTypes(); err != nil {
+		return err
+	}
+	if err := LoadPackOptions(); err != nil {
+		return err
+	}
 
 	// 过滤指定文件
 	parseList := XlsxList
@@ -214,6 +270,8 @@ func Run(handler *ParseHandler) error {
 		return errors.New("no valid .xlsx files found")
 	}
 
+	resetSqliteTables()
+	resetLuaAliasClasses()
 	defer SaveExportTime()
 	EventChan = make(chan *ParseEvent, xlsxCount*2) // *2 因为每个任务有 start 和 finish 两个事件
 
@@ -247,17 +305,73 @@ func Run(handler *ParseHandler) error {
 	wg.Wait()
 
 	// 生成/更新 GameTableProxy.cs（csharp 模式下）
-	sep := string(filepath.Separator)
-	for _, format := range GFlags.Server {
-		if format == "csharp" {
-			outdir := GFlags.Output + sep + "server" + sep + "csharp" + sep
-			UpdateGameTableProxy(outdir, "server")
+	for _, t := range ExportTargets() {
+		for _, format := range t.Formats {
+			if format == "csharp" {
+				UpdateGameTableProxy(t.outDir(format), t.Name)
+			}
 		}
 	}
-	for _, format := range GFlags.Client {
-		if format == "csharp" {
-			outdir := GFlags.Output + sep + "client" + sep + "csharp" + sep
-			UpdateGameTableProxy(outdir, "client")
+
+	// 写入 sqlite 数据库
+	var dbErr error
+	for _, t := range ExportTargets() {
+		for _, format := range t.Formats {
+			if format == "sqlite" {
+				if err := WriteSqliteDB(t.outDir(format), t.Name); err != nil {
+					dbErr = err
+				}
+			}
+		}
+	}
+
+	// 合并导出文件
+	var bundleErr error
+	if len(GFlags.Bundle) > 0 {
+		for _, t := range ExportTargets() {
+			for _, format := range t.Formats {
+				if err := WriteBundle(t.outDir(format), format); err != nil {
+					bundleErr = err
+				}
+				if format == "csharp" {
+					WriteTableBundleCSharp(t.outDir(format))
+				}
+			}
+		}
+	}
+
+	// 生成解码代码
+	if packEnabled() {
+		for _, t := range ExportTargets() {
+			for _, format := range t.Formats {
+				if format == "csharp" {
+					WriteTableCodecCSharp(t.outDir(format))
+				}
+			}
+		}
+	}
+
+	// 生成共享类型定义文件(lua 还包括配置表中的结构体别名)
+	for _, t := range ExportTargets() {
+		for _, format := range t.Formats {
+			switch format {
+			case "csharp":
+				if len(SharedTypeNames) > 0 {
+					WriteSharedTypesCSharp(t.outDir(format))
+				}
+			case "lua":
+				WriteSharedTypesLua(t.outDir(format))
+			}
+		}
+	}
+
+	// 生成导出文件清单
+	var manifestErr error
+	for _, t := range ExportTargets() {
+		for _, format := range t.Formats {
+			if err := WriteManifest(t.outDir(format), t.Name, format); err != nil {
+				manifestErr = err
+			}
 		}
 	}
 
@@ -268,5 +382,5 @@ func Run(handler *ParseHandler) error {
 	}
 
 	ExportCost = GetDurationMs(startTime)
-	return nil
+	return errors.Join(dbErr, bundleErr, manifestErr)
 }
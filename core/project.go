@@ -0,0 +1,286 @@
+// 项目配置文件(excelparser.yaml)，命令行和界面共用
+// 未指定 --config 时从当前目录向上查找，命令行参数优先于配置文件
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const ProjectConfigName = "excelparser.yaml"
+
+// 项目配置
+type ProjectConfig struct {
+	Path        string                    `yaml:"path"`         // 配置表目录
+	Output      string                    `yaml:"output"`       // 导出路径
+	Types       []string                  `yaml:"types"`        // 共享类型定义文件
+	I18n        ProjectI18n               `yaml:"i18n"`         // 国际化设置
+	Targets     map[string]*ProjectTarget `yaml:"targets"`      // 导出目标(server、client 为内置目标)
+	Compact     bool                      `yaml:"compact"`      // 紧凑导出
+	Indent      bool                      `yaml:"indent"`       // json格式化
+	Formula     bool                      `yaml:"formula"`      // 重新计算公式单元格
+	LuaOpt      bool                      `yaml:"lua_opt"`      // lua 优化导出
+	LuaColumns  []string                  `yaml:"lua_columns"`  // 使用列布局导出的 lua 配置表
+	Tags        []string                  `yaml:"tags"`         // 导出的行标签
+	Bundle      string                    `yaml:"bundle"`       // 合并导出文件名
+	Compress    string                    `yaml:"compress"`     // 导出文件压缩算法
+	EncryptKey  string                    `yaml:"encrypt_key"`  // 导出文件加密密钥
+	ErrorReport bool                      `yaml:"error_report"` // 导出失败时输出错误报告工作簿
+	Header      *HeaderLayout             `yaml:"header"`       // 表头行布局
+	Tables      map[string]*TableConfig   `yaml:"tables"`       // 单表设置(导出名或文件名)
+	dir         string                    // 配置文件所在目录(相对路径的基准)
+}
+
+// 国际化设置
+type ProjectI18n struct {
+	Path string `yaml:"path"` // po 文件目录
+	Lang string `yaml:"lang"` // 语言
+}
+
+// 导出目标设置
+type ProjectTarget struct {
+	Formats []string `yaml:"formats"` // 导出格式
+	Output  string   `yaml:"output"`  // 输出目录(空=output/目标名)
+}
+
+// 表头行布局(表头各行的行号，1~4)
+type HeaderLayout struct {
+	Name int `yaml:"name"` // 字段名行
+	Type int `yaml:"type"` // 字段类型行
+	Mode int `yaml:"mode"` // 导出模式行
+	Desc int `yaml:"desc"` // 字段描述行
+}
+
+// 单表设置，未设置的项使用全局设置
+type TableConfig struct {
+	Compact *bool  `yaml:"compact"` // 紧凑导出
+	Indent  *bool  `yaml:"indent"`  // json格式化
+	Key     string `yaml:"key"`     // key 字段类型(覆盖表头中的类型)
+}
+
+// 从 dir 开始向上查找项目配置文件，找不到时返回空字符串
+func FindProjectConfig(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		path := filepath.Join(dir, ProjectConfigName)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// 读取项目配置文件
+func LoadProjectConfig(path string) (*ProjectConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &ProjectConfig{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	c.dir = filepath.Dir(path)
+	if err := c.check(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return c, nil
+}
+
+// 查找并应用项目配置，path 为空时从当前目录向上查找
+// set 为命令行中指定的参数名，这些参数不使用配置文件中的值
+// 返回使用的配置文件路径(没有配置文件时为空)
+func ApplyProjectConfig(path string, set map[string]bool) (string, error) {
+	if len(path) == 0 {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		if path = FindProjectConfig(wd); len(path) == 0 {
+			return "", nil
+		}
+	}
+	c, err := LoadProjectConfig(path)
+	if err != nil {
+		return "", err
+	}
+	c.Apply(set)
+	return path, nil
+}
+
+func (c *ProjectConfig) check() error {
+	for name, t := range c.Targets {
+		if t == nil || len(t.Formats) == 0 {
+			return fmt.Errorf("导出目标[%s]未指定导出格式", name)
+		}
+		if name == "server" || name == "client" {
+			continue
+		}
+		if err := checkTargetName(name); err != nil {
+			return fmt.Errorf("导出目标名[%s]不合法: %v", name, err)
+		}
+	}
+	if h := c.Header; h != nil {
+		lines := []int{h.Name, h.Type, h.Mode, h.Desc}
+		for _, l := range lines {
+			if l < 1 || l > HeadLineNum {
+				return fmt.Errorf("表头行布局错误(name、type、mode、desc 必须为 1~%d)", HeadLineNum)
+			}
+		}
+		slices.Sort(lines)
+		if len(slices.Compact(lines)) != HeadLineNum {
+			return errors.New("表头行布局错误(行号重复)")
+		}
+	}
+	for name, t := range c.Tables {
+		if t == nil || len(t.Key) == 0 {
+			continue
+		}
+		if !slices.Contains([]string{"int", "uint", "string"}, t.Key) {
+			return fmt.Errorf("配置表[%s]的 key 类型[%s]不合法(int、uint、string)", name, t.Key)
+		}
+	}
+	return nil
+}
+
+// 相对路径以配置文件所在目录为基准
+func (c *ProjectConfig) resolve(path string) string {
+	if len(path) == 0 || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(c.dir, path)
+}
+
+// 应用到导出选项，set 中的参数保持命令行指定的值
+func (c *ProjectConfig) Apply(set map[string]bool) {
+	setStr := func(name string, dst *string, val string) {
+		if !set[name] && len(val) > 0 {
+			*dst = val
+		}
+	}
+	setBool := func(name string, dst *bool, val bool) {
+		if !set[name] && val {
+			*dst = val
+		}
+	}
+	setSlice := func(name string, dst *[]string, val []string) {
+		if !set[name] && len(val) > 0 {
+			*dst = val
+		}
+	}
+
+	setStr("path", &GFlags.Path, c.resolve(c.Path))
+	setStr("output", &GFlags.Output, c.resolve(c.Output))
+	types := make([]string, 0, len(c.Types))
+	for _, t := range c.Types {
+		types = append(types, c.resolve(t))
+	}
+	setStr("types", &GFlags.Types, strings.Join(types, ","))
+	setStr("i18n", &GFlags.I18nPath, c.resolve(c.I18n.Path))
+	setStr("lang", &GFlags.I18nLang, c.I18n.Lang)
+	setBool("compact", &GFlags.Compact, c.Compact)
+	setBool("indent", &GFlags.Pretty, c.Indent)
+	setBool("formula", &GFlags.Formula, c.Formula)
+	setBool("lua-opt", &GFlags.LuaOpt, c.LuaOpt)
+	setBool("error-report", &GFlags.ErrorReport, c.ErrorReport)
+	setSlice("lua-columns", &GFlags.LuaCols, c.LuaColumns)
+	setSlice("tags", &GFlags.Tags, c.Tags)
+	setStr("bundle", &GFlags.Bundle, c.Bundle)
+	setStr("compress", &GFlags.Compress, c.Compress)
+	if strings.HasPrefix(c.EncryptKey, "env:") {
+		setStr("encrypt-key", &GFlags.EncryptKey, c.EncryptKey)
+	} else {
+		setStr("encrypt-key", &GFlags.EncryptKey, c.resolve(c.EncryptKey))
+	}
+
+	// 导出目标，命令行指定 --output 时不使用配置中的目标输出目录
+	GFlags.Outputs = make(map[string]string)
+	targets := make([]Target, 0, len(c.Targets))
+	for _, name := range slices.Sorted(maps.Keys(c.Targets)) {
+		t := c.Targets[name]
+		output := ternary(set["output"], "", c.resolve(t.Output))
+		switch name {
+		case "server":
+			setSlice("server", &GFlags.Server, t.Formats)
+			GFlags.Outputs[name] = output
+		case "client":
+			setSlice("client", &GFlags.Client, t.Formats)
+			GFlags.Outputs[name] = output
+		default:
+			targets = append(targets, Target{Name: name, Formats: t.Formats, Output: output})
+		}
+	}
+	if !set["target"] && len(targets) > 0 {
+		GFlags.Targets = targets
+	}
+
+	setHeaderLayout(c.Header)
+	TableConfigs = c.Tables
+}
+
+// 设置表头行布局，nil 时恢复默认布局
+func setHeaderLayout(h *HeaderLayout) {
+	if h == nil {
+		h = &HeaderLayout{Name: 1, Type: 2, Mode: 3, Desc: 4}
+	}
+	NameLine, TypeLine, ModeLine, DescLine = h.Name, h.Type, h.Mode, h.Desc
+}
+
+// 按表头行布局排列字段名、类型、导出模式、描述
+func headLines[T any](name, typ, mode, desc T) []T {
+	lines := make([]T, HeadLineNum)
+	lines[NameLine-1], lines[TypeLine-1], lines[ModeLine-1], lines[DescLine-1] = name, typ, mode, desc
+	return lines
+}
+
+// 单表设置(按导出名或文件名查找)
+func (x *Xlsx) tableConfig() *TableConfig {
+	if t, ok := TableConfigs[x.OutName]; ok && t != nil {
+		return t
+	}
+	if t, ok := TableConfigs[x.FileName]; ok && t != nil {
+		return t
+	}
+	return nil
+}
+
+// 应用单表设置，单表设置优先于全局设置
+func (x *Xlsx) applyTableConfig() {
+	x.Compact, x.Pretty = GFlags.Compact, GFlags.Pretty
+	t := x.tableConfig()
+	if t == nil {
+		return
+	}
+	if t.Compact != nil {
+		x.Compact = *t.Compact
+	}
+	if t.Indent != nil {
+		x.Pretty = *t.Indent
+	}
+	if len(t.Key) > 0 && len(x.Types) > 0 {
+		x.Types = slices.Clone(x.Types)
+		x.Types[0] = t.Key
+	}
+}
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// 保存项目配置修改的全局设置，测试结束后恢复
+func saveProjectGlobals(t *testing.T) {
+	t.Helper()
+	flags, tables := GFlags, TableConfigs
+	lines := []int{NameLine, TypeLine, ModeLine, DescLine}
+	t.Cleanup(func() {
+		GFlags, TableConfigs = flags, tables
+		NameLine, TypeLine, ModeLine, DescLine = lines[0], lines[1], lines[2], lines[3]
+	})
+}
+
+func writeProjectConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ProjectConfigName)
+	if err := os.WriteFile(path, []byte(content), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestProjectConfigCheck(t *testing.T) {
+	tests := []struct {
+		yaml string
+		err  string
+	}{
+		{yaml: ""},
+		{yaml: "targets:\n  server: {formats: [lua]}\n  battle: {formats: [json], output: out/battle}\n"},
+		{yaml: "header: {name: 2, type: 1, mode: 3, desc: 4}\ntables:\n  item: {key: string}\n  shop:\n"},
+		{yaml: "targets:\n  battle: {output: out}\n", err: "导出目标[battle]未指定导出格式"},
+		{yaml: "targets:\n  client:\n", err: "导出目标[client]未指定导出格式"},
+		{yaml: "targets:\n  x: {formats: [lua]}\n", err: "导出目标名[x]不合法"},
+		{yaml: "targets:\n  a/b: {formats: [lua]}\n", err: "导出目标名[a/b]不合法"},
+		{yaml: "header: {name: 1, type: 2, mode: 3, desc: 5}\n", err: "必须为 1~4"},
+		{yaml: "header: {name: 1, type: 1, mode: 3, desc: 4}\n", err: "行号重复"},
+		{yaml: "tables:\n  item: {key: float}\n", err: "配置表[item]的 key 类型[float]不合法"},
+		{yaml: "compresss: gzip\n", err: "field compresss not found"},
+	}
+	for _, tt := range tests {
+		_, err := LoadProjectConfig(writeProjectConfig(t, tt.yaml))
+		if len(tt.err) == 0 {
+			if err != nil {
+				t.Errorf("%q: unexpected error %v", tt.yaml, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%q: error %v, want %q", tt.yaml, err, tt.err)
+		}
+	}
+}
+
+func TestProjectConfigApply(t *testing.T) {
+	saveProjectGlobals(t)
+	path := writeProjectConfig(t, `
+path: xlsx
+output: /data/out
+types: [types.yaml]
+compact: true
+indent: true
+tags: [dev]
+compress: zstd
+encrypt_key: env:TABLE_KEY
+header: {name: 2, type: 1, mode: 3, desc: 4}
+targets:
+  server: {formats: [lua], output: out/server}
+  client: {formats: [json]}
+  battle: {formats: [json]}
+tables:
+  item: {key: string}
+`)
+	dir := filepath.Dir(path)
+
+	// 命令行指定的参数
+	GFlags = Flags{Compact: false, Server: []string{"json"}, Tags: []string{"release"}, Output: "cli-out", Compress: "gzip"}
+	set := map[string]bool{"compact": true, "server": true, "tags": true, "output": true}
+	if _, err := ApplyProjectConfig(path, set); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  any
+		want any
+	}{
+		{"path", GFlags.Path, filepath.Join(dir, "xlsx")},
+		{"output", GFlags.Output, "cli-out"},
+		{"types", GFlags.Types, filepath.Join(dir, "types.yaml")},
+		{"compact", GFlags.Compact, false},
+		{"indent", GFlags.Pretty, true},
+		{"tags", strings.Join(GFlags.Tags, ","), "release"},
+		{"compress", GFlags.Compress, "zstd"},
+		{"encrypt-key", GFlags.EncryptKey, "env:TABLE_KEY"},
+		{"server", strings.Join(GFlags.Server, ","), "json"},
+		{"client", strings.Join(GFlags.Client, ","), "json"},
+		// 命令行指定 --output 时不使用配置中的目标输出目录
+		{"server output", GFlags.Outputs["server"], ""},
+		{"targets", len(GFlags.Targets), 1},
+		{"header", []int{NameLine, TypeLine, ModeLine, DescLine}, []int{2, 1, 3, 4}},
+		{"tables", TableConfigs["item"].Key, "string"},
+	}
+	for _, tt := range tests {
+		if a, ok := tt.got.([]int); ok {
+			if !slices.Equal(a, tt.want.([]int)) {
+				t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		} else if tt.got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+
+	// 没有 --output 时使用配置中的目标输出目录
+	GFlags = Flags{}
+	if _, err := ApplyProjectConfig(path, map[string]bool{}); err != nil {
+		t.Fatal(err)
+	}
+	if GFlags.Output != "/data/out" || GFlags.Outputs["server"] != filepath.Join(dir, "out", "server") || GFlags.Outputs["client"] != "" {
+		t.Errorf("output = %s, outputs = %v", GFlags.Output, GFlags.Outputs)
+	}
+	if len(GFlags.Targets) != 1 || GFlags.Targets[0].Name != "battle" || GFlags.Targets[0].Output != "" {
+		t.Errorf("targets = %+v", GFlags.Targets)
+	}
+}
+
+func TestSettingsHash(t *testing.T) {
+	saveProjectGlobals(t)
+	setPackOptions(t, CompressNone, nil)
+	GFlags = Flags{Output: "out", Server: []string{"lua"}}
+	TableConfigs = nil
+	x := &Xlsx{OutName: "item", FileName: "D-道具@item"}
+	hash := x.settingsHash("server")
+	if x.settingsHash("server") != hash {
+		t.Fatal("相同设置的摘要不同")
+	}
+
+	yes := true
+	tests := []struct {
+		name   string
+		change func()
+	}{
+		{"compact", func() { GFlags.Compact = true }},
+		{"tags", func() { GFlags.Tags = []string{"dev"} }},
+		{"bundle", func() { GFlags.Bundle = "all" }},
+		{"compress", func() { GFlags.Compress = "gzip" }},
+		{"encrypt key", func() { packKey = make([]byte, 16) }},
+		{"formats", func() { GFlags.Server = []string{"lua", "json"} }},
+		{"target output", func() { GFlags.Outputs = map[string]string{"server": "out/s"} }},
+		{"header", func() { NameLine, TypeLine = 2, 1 }},
+		{"table config", func() { TableConfigs = map[string]*TableConfig{"item": {Compact: &yes}} }},
+	}
+	for _, tt := range tests {
+		flags := GFlags
+		tt.change()
+		if x.settingsHash("server") == hash {
+			t.Errorf("修改 %s 后摘要不变", tt.name)
+		}
+		GFlags, TableConfigs, packKey = flags, nil, nil
+		NameLine, TypeLine = 1, 2
+	}
+	// 其他目标的设置不影响
+	GFlags.Client = []string{"json"}
+	if x.settingsHash("server") != hash {
+		t.Error("修改 client 后 server 的摘要变化")
+	}
+}
@@ -0,0 +1,332 @@
+// 共享类型注册表
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	SharedTypeNames []string                                         // 共享类型名列表(按定义顺序)
+	SharedTypesTime uint64                                           // 共享类型定义文件的最后修改时间
+	TypeNameRe      = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`) // 类型名和字段名正则表达式
+)
+
+// 共享类型 yaml 定义
+// eg.:
+//
+//   - name: Reward
+//     desc: 奖励
+//     fields:
+//   - {name: id, type: int, desc: 道具id}
+//   - {name: num, type: int, desc: 数量}
+type sharedTypeDef struct {
+	Name   string           `yaml:"name"`
+	Desc   string           `yaml:"desc"`
+	Fields []sharedFieldDef `yaml:"fields"`
+	source string
+}
+
+type sharedFieldDef struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+	Desc string `yaml:"desc"`
+}
+
+// 是否是共享类型定义工作簿(eg.: types@类型.xlsx)
+func isTypesXlsx(fileName string) bool {
+	return fileName == "types" || strings.HasPrefix(fileName, "types@") || strings.HasSuffix(fileName, "@types")
+}
+
+// 共享类型定义文件列表
+// 优先使用 --types 指定的文件，否则使用配置目录下的 types.yaml/types.yml 及 types@*.xlsx
+func sharedTypesFiles(xlsxPath string) []string {
+	if len(GFlags.Types) > 0 {
+		return strings.Split(GFlags.Types, ",")
+	}
+	files := make([]string, 0, 2)
+	for _, name := range []string{"types.yaml", "types.yml"} {
+		path := filepath.Join(xlsxPath, name)
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	return append(files, TypesXlsx...)
+}
+
+// 加载共享类型注册表
+func LoadSharedTypes() error {
+	SharedTypes = make(map[string]*SharedType)
+	SharedTypeNames = SharedTypeNames[:0]
+	SharedTypesTime = 0
+
+	xlsxPath, err := CheckPathValid(GFlags.Path)
+	if err != nil {
+		return err
+	}
+
+	defs := make([]*sharedTypeDef, 0)
+	for _, file := range sharedTypesFiles(xlsxPath) {
+		info, err := os.Stat(file)
+		if err != nil {
+			return fmt.Errorf("共享类型文件[%s]不存在", file)
+		}
+		SharedTypesTime = max(SharedTypesTime, uint64(info.ModTime().UnixNano()/1000000))
+
+		var fdefs []*sharedTypeDef
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".yaml", ".yml":
+			fdefs, err = readTypesYaml(file)
+		case ".xlsx":
+			fdefs, err = readTypesXlsx(file)
+		default:
+			err = errors.New("只支持 yaml 和 xlsx 文件")
+		}
+		if err != nil {
+			return fmt.Errorf("共享类型文件[%s]读取失败: %v", filepath.Base(file), err)
+		}
+		defs = append(defs, fdefs...)
+	}
+	return registerSharedTypes(defs)
+}
+
+func readTypesYaml(file string) ([]*sharedTypeDef, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	defs := make([]*sharedTypeDef, 0)
+	if err := yaml.Unmarshal(data, &defs); err != nil {
+		return nil, err
+	}
+	for _, d := range defs {
+		d.source = filepath.Base(file)
+	}
+	return defs, nil
+}
+
+// 工作簿格式: types 工作表(不存在时使用第一个工作表)，第一行为表头
+// 列: 类型名 | 字段名 | 字段类型 | 描述
+// 类型名为空的行属于上一个类型，字段名为空的行为类型描述
+func readTypesXlsx(file string) ([]*sharedTypeDef, error) {
+	f, err := excelize.OpenFile(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sheet := f.GetSheetName(0)
+	if idx, _ := f.GetSheetIndex("types"); idx >= 0 {
+		sheet = "types"
+	}
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	defs := make([]*sharedTypeDef, 0)
+	var cur *sharedTypeDef
+	for i, row := range rows {
+		if i == 0 {
+			continue
+		}
+		cols := make([]string, 4)
+		for j := range cols {
+			if j < len(row) {
+				cols[j] = strings.TrimSpace(row[j])
+			}
+		}
+		if len(cols[0]) > 0 {
+			cur = &sharedTypeDef{Name: cols[0], source: fmt.Sprintf("%s:%d", filepath.Base(file), i+1)}
+			defs = append(defs, cur)
+		}
+		if len(cols[1]) == 0 && len(cols[2]) == 0 {
+			if cur != nil && len(cols[0]) > 0 {
+				cur.Desc = cols[3]
+			}
+			continue
+		}
+		if cur == nil {
+			return nil, fmt.Errorf("第%d行缺少类型名", i+1)
+		}
+		cur.Fields = append(cur.Fields, sharedFieldDef{Name: cols[1], Type: cols[2], Desc: cols[3]})
+	}
+	return defs, nil
+}
+
+// 注册共享类型
+// 类型之间可以相互引用(不能循环引用)，同名类型定义一致时合并，不一致时报错
+func registerSharedTypes(defs []*sharedTypeDef) error {
+	pending := make([]*sharedTypeDef, 0, len(defs))
+	for _, d := range defs {
+		if !TypeNameRe.MatchString(d.Name) {
+			return fmt.Errorf("共享类型名[%s]不合法(%s)", d.Name, d.source)
+		}
+		if parseType(d.Name).Kind != TNone {
+			return fmt.Errorf("共享类型名[%s]与内置类型冲突(%s)", d.Name, d.source)
+		}
+		if len(d.Fields) == 0 {
+			return fmt.Errorf("共享类型[%s]没有字段定义(%s)", d.Name, d.source)
+		}
+		pending = append(pending, d)
+	}
+
+	for len(pending) > 0 {
+		rest := pending[:0:0]
+		var lastErr error
+		for _, d := range pending {
+			st, err := buildSharedType(d)
+			if err != nil {
+				lastErr = err
+				rest = append(rest, d)
+				continue
+			}
+			if old, ok := SharedTypes[d.Name]; ok {
+				if old.structSignature() != st.structSignature() {
+					return fmt.Errorf("共享类型[%s]定义冲突(%s 和 %s)", d.Name, old.Source, st.Source)
+				}
+				continue
+			}
+			SharedTypes[d.Name] = st
+			SharedTypeNames = append(SharedTypeNames, d.Name)
+		}
+		if len(rest) == len(pending) {
+			// 无法继续解析，存在未定义或循环引用的类型
+			return lastErr
+		}
+		pending = rest
+	}
+	return nil
+}
+
+func buildSharedType(d *sharedTypeDef) (*SharedType, error) {
+	t := &Type{Kind: TStruct, Cap: -1, Aname: d.Name, Ftypes: make(map[string]*Type)}
+	st := &SharedType{Type: t, Name: d.Name, Desc: d.Desc, Fdescs: make(map[string]string), Source: d.source}
+	for _, fd := range d.Fields {
+		name := strings.TrimSpace(fd.Name)
+		if !TypeNameRe.MatchString(name) {
+			return nil, fmt.Errorf("共享类型[%s]的字段名[%s]不合法(%s)", d.Name, name, d.source)
+		}
+		if _, ok := t.Ftypes[name]; ok {
+			return nil, fmt.Errorf("共享类型[%s]的字段[%s]重复(%s)", d.Name, name, d.source)
+		}
+		ft := parseType(strings.TrimSpace(fd.Type))
+		if err := checkSharedFieldType(ft); err != nil {
+			return nil, fmt.Errorf("共享类型[%s]的字段[%s]类型错误: %v(%s)", d.Name, name, err, d.source)
+		}
+		t.Fnames = append(t.Fnames, name)
+		t.Ftypes[name] = ft
+		st.Fdescs[name] = fd.Desc
+	}
+	return st, nil
+}
+
+func checkSharedFieldType(t *Type) error {
+	if !t.isVaild(false) || len(t.Expr) > 0 {
+		return errors.New("类型不合法或引用了未定义的类型")
+	}
+	switch t.Kind {
+	case TArray, TMap:
+		return checkSharedFieldType(t.Vtype)
+	case TStruct:
+		if len(t.Fnames) == 0 {
+			return errors.New("类型不合法或引用了未定义的类型")
+		}
+		for _, name := range t.Fnames {
+			if err := checkSharedFieldType(t.Ftypes[name]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// 查找共享类型
+func findSharedType(name string) *SharedType {
+	if st, ok := SharedTypes[name]; ok {
+		return st
+	}
+	return nil
+}
+
+// 是否是共享类型
+func (t *Type) isShared() bool {
+	return t.Kind == TStruct && len(t.Aname) > 0 && findSharedType(t.Aname) != nil
+}
+
+// 类型签名，用于比较类型定义是否一致
+func (t *Type) signature() string {
+	switch t.Kind {
+	case TInt:
+		return "int"
+	case TUint:
+		return "uint"
+	case TFloat:
+		return "float"
+	case TBool:
+		return "bool"
+	case TString:
+		return ternary(t.I18n, "i18n", "string")
+	case TArray:
+		return "[" + ternary(t.Cap < 0, "", strconv.Itoa(t.Cap)) + "]" + t.Vtype.signature()
+	case TMap:
+		return "map[" + t.Ktype.signature() + "]" + t.Vtype.signature()
+	case TStruct:
+		if t.isShared() {
+			return t.Aname
+		}
+		return t.structSignature()
+	case TJson:
+		if t.Vtype != nil {
+			return "json:" + t.Vtype.signature()
+		}
+		return "json"
+	case TAny:
+		return "any"
+	}
+	return "?"
+}
+
+// 结构体字段签名(按字段顺序展开)
+func (t *Type) structSignature() string {
+	parts := make([]string, 0, len(t.Fnames))
+	for _, name := range t.Fnames {
+		parts = append(parts, name+"="+t.Ftypes[name].signature())
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// 多列结构体字段签名
+func (f *Field) columnsSignature() string {
+	parts := make([]string, 0, len(f.Vals))
+	for _, v := range f.Vals {
+		parts = append(parts, v.Name+"="+v.Type.signature())
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// 检查多列结构体与共享类型的定义是否一致
+func (x *Xlsx) checkStructShape(field *Field) {
+	if len(field.Vals) == 0 || !field.isShared() {
+		return
+	}
+
+	st := findSharedType(field.Aname)
+	if field.columnsSignature() != st.structSignature() {
+		x.sprintfCellError(TypeLine, field.Index+1, "字段%s与共享类型%s的定义不一致(%s)", field.Name, st.Name, st.structSignature())
+	}
+	for _, v := range field.Vals {
+		if len(v.Mode) > 0 {
+			x.sprintfCellError(ModeLine, v.Index+1, "共享类型%s的字段不能设置导出模式", st.Name)
+		}
+	}
+}
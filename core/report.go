@@ -0,0 +1,198 @@
+// 错误报告工作簿(--error-report)，导出失败时输出标记了错误单元格的配置表副本
+
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	reportDir    = "errors"      // 错误报告输出目录(导出路径下)
+	reportSheet  = "errors"      // 错误列表工作表名
+	reportFill   = "FFC7CE"      // 错误单元格填充色
+	reportFont   = "9C0006"      // 错误单元格字体颜色
+	reportAuthor = "excelparser" // 错误批注作者
+)
+
+// 错误报告文件路径(eg.: out/errors/item.errors.xlsx)
+func (x *Xlsx) errorReportPath() string {
+	return filepath.Join(GFlags.Output, reportDir, x.OutName+".errors.xlsx")
+}
+
+// 写入错误报告，没有错误时删除上次的错误报告
+// 需要在配置表工作表打开时调用(csv/tsv/ods 由工作表内容生成工作簿)
+func (x *Xlsx) writeErrorReport() {
+	path := x.errorReportPath()
+	x.ErrorReport = ""
+	if len(x.Errors) == 0 {
+		os.Remove(path)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		x.sprintfError("错误报告目录创建失败: %v", err)
+		return
+	}
+	if err := x.saveErrorReport(path); err != nil {
+		x.sprintfError("错误报告生成失败: %v", err)
+		return
+	}
+	x.ErrorReport = path
+}
+
+func (x *Xlsx) saveErrorReport(path string) error {
+	var f *excelize.File
+	var err error
+	if strings.ToLower(filepath.Ext(x.PathName)) == ".xlsx" {
+		f, err = excelize.OpenFile(x.PathName)
+	} else {
+		f, err = x.newReportFile()
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sheet := x.SheetName
+	if idx, _ := f.GetSheetIndex(sheet); idx < 0 {
+		return errNoDataSheet
+	}
+
+	// 同一个单元格的多个错误合并
+	cells := make([]string, 0)
+	msgs := make(map[string][]string)
+	for _, ce := range x.CellErrors {
+		if _, ok := msgs[ce.Cell]; !ok {
+			cells = append(cells, ce.Cell)
+		}
+		msgs[ce.Cell] = append(msgs[ce.Cell], ce.Text)
+	}
+
+	comments, err := f.GetComments(sheet)
+	if err != nil {
+		return err
+	}
+	origins := make(map[string]string)
+	for _, c := range comments {
+		origins[c.Cell] = commentText(c)
+	}
+	styles := make(map[int]int)
+	for _, cell := range cells {
+		// 错误单元格填充为红色(保留原样式的其他设置)
+		sid, err := f.GetCellStyle(sheet, cell)
+		if err != nil {
+			return err
+		}
+		if _, ok := styles[sid]; !ok {
+			style, err := f.GetStyle(sid)
+			if err != nil {
+				return err
+			}
+			if style.Font == nil {
+				style.Font = &excelize.Font{}
+			}
+			style.Font.Color = reportFont
+			style.Fill = excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{reportFill}}
+			if styles[sid], err = f.NewStyle(style); err != nil {
+				return err
+			}
+		}
+		if err := f.SetCellStyle(sheet, cell, cell, styles[sid]); err != nil {
+			return err
+		}
+
+		// 错误信息批注(原批注保留在错误信息后面)
+		paragraph := []excelize.RichTextRun{
+			{Text: "错误:\n", Font: &excelize.Font{Bold: true}},
+			{Text: strings.Join(msgs[cell], "\n")},
+		}
+		if origin, ok := origins[cell]; ok {
+			if err := f.DeleteComment(sheet, cell); err != nil {
+				return err
+			}
+			paragraph = append(paragraph, excelize.RichTextRun{Text: "\n\n" + origin})
+		}
+		err = f.AddComment(sheet, excelize.Comment{Cell: cell, Author: reportAuthor, Paragraph: paragraph})
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := x.writeReportSheet(f); err != nil {
+		return err
+	}
+	return f.SaveAs(path)
+}
+
+// 由工作表内容生成工作簿(csv/tsv/ods)
+func (x *Xlsx) newReportFile() (*excelize.File, error) {
+	lines, err := x.Sheet.Lines()
+	if err != nil {
+		return nil, err
+	}
+	f := excelize.NewFile()
+	if err := f.SetSheetName(f.GetSheetName(0), x.SheetName); err != nil {
+		f.Close()
+		return nil, err
+	}
+	for i, line := range lines {
+		for j, v := range line {
+			if len(v) == 0 {
+				continue
+			}
+			axis, _ := excelize.CoordinatesToCellName(ternary(x.Vertical, i, j)+1, ternary(x.Vertical, j, i)+1)
+			f.SetCellStr(x.SheetName, axis, v)
+		}
+	}
+	if merges, err := x.Sheet.MergeCells(); err == nil {
+		for _, m := range merges {
+			f.MergeCell(x.SheetName, m.Start, m.End)
+		}
+	}
+	if comments, err := x.Sheet.Comments(); err == nil {
+		for _, c := range comments {
+			f.AddComment(x.SheetName, excelize.Comment{Cell: c.Cell, Author: reportAuthor, Paragraph: []excelize.RichTextRun{{Text: c.Text}}})
+		}
+	}
+	return f, nil
+}
+
+// 错误列表工作表(所有单元格错误，以及其他错误信息)
+func (x *Xlsx) writeReportSheet(f *excelize.File) error {
+	name := reportSheet
+	for i := 1; ; i++ {
+		if idx, _ := f.GetSheetIndex(name); idx < 0 {
+			break
+		}
+		name = fmt.Sprintf("%s%d", reportSheet, i)
+	}
+	if _, err := f.NewSheet(name); err != nil {
+		return err
+	}
+	rows := [][]any{{"单元格", "错误信息"}}
+	cellErrs := make(map[string]bool)
+	for _, ce := range x.CellErrors {
+		rows = append(rows, []any{ce.Cell, ce.Text})
+		cellErrs[fmt.Sprintf("[%s]%s", ce.Cell, ce.Text)] = true
+	}
+	for _, e := range x.Errors {
+		if !cellErrs[e] && e != "..." {
+			rows = append(rows, []any{"", e})
+		}
+	}
+	for i, row := range rows {
+		axis, _ := excelize.CoordinatesToCellName(1, i+1)
+		if err := f.SetSheetRow(name, axis, &row); err != nil {
+			return err
+		}
+	}
+	if style, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}}); err == nil {
+		f.SetCellStyle(name, "A1", "B1", style)
+	}
+	f.SetColWidth(name, "B", "B", 80)
+	return nil
+}
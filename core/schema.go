@@ -0,0 +1,272 @@
+// 由表结构定义生成配置表工作簿(new 命令)
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// 配置表结构定义(yaml/json)
+// eg.:
+//
+//	vertical: false
+//	fields:
+//	  - {name: id, type: int, desc: 配置唯一id}
+//	  - {name: quality, type: "int(1|2|3)", desc: 品质}
+//	  - {name: rewards, type: "[]struct#Reward", size: 2, desc: 奖励, fields: [{name: id, type: int}, {name: num, type: int}]}
+type TableSchema struct {
+	Sheet    string         `yaml:"sheet"`    // 工作表名(默认 data，纵向表为 vdata)
+	Vertical bool           `yaml:"vertical"` // 纵向表(未指定工作表名时有效)
+	Fields   []*SchemaField `yaml:"fields"`   // 字段列表
+}
+
+// 字段定义
+// 数组/map 默认使用单元格填写，指定 size 时生成多列(元素列)
+// 结构体指定 fields 时生成多列(子字段列)，数组/map 的 fields 为元素结构体的子字段
+type SchemaField struct {
+	Name    string         `yaml:"name"`    // 字段名
+	Type    string         `yaml:"type"`    // 字段类型(与表头类型格式相同)
+	Mode    string         `yaml:"mode"`    // 导出模式
+	Desc    string         `yaml:"desc"`    // 字段描述
+	Comment string         `yaml:"comment"` // 字段批注(描述单元格的批注)
+	Size    int            `yaml:"size"`    // 多列数组/map 的元素个数
+	Fields  []*SchemaField `yaml:"fields"`  // 多列结构体的子字段
+}
+
+// 表头的一列
+type schemaColumn struct {
+	name    string
+	typ     string
+	mode    string
+	desc    string
+	comment string
+	prompt  string // 输入提示
+	element bool   // 数组/map 的元素列(字段名为空时与相邻的元素列合并字段名单元格)
+}
+
+// ReadSchema 读取表结构定义文件
+// 支持 yaml/json 结构定义，以及 go(struct)、c#(class) 类型定义，typeName 为空时使用第一个类型
+func ReadSchema(path, typeName string) (*TableSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+		s := &TableSchema{}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(s); err != nil {
+			return nil, fmt.Errorf("结构定义格式错误: %v", err)
+		}
+		return s, nil
+	case ".go":
+		return readGoSchema(path, data, typeName)
+	case ".cs":
+		return readCSharpSchema(data, typeName)
+	default:
+		return nil, errors.New("只支持 yaml、json、go 和 cs 文件")
+	}
+}
+
+// NewXlsxFromSchema 生成配置表工作簿(只有表头)，生成后按配置表检查表头
+func NewXlsxFromSchema(s *TableSchema, out string) error {
+	sheet := s.Sheet
+	if len(sheet) == 0 {
+		sheet = ternary(s.Vertical, "vdata", "data")
+	}
+	name, vertical := findDataSheetName([]string{sheet})
+	if len(name) == 0 {
+		return fmt.Errorf("工作表名[%s]错误(必须为 data 或者 vdata)", sheet)
+	}
+	if len(s.Fields) == 0 {
+		return errors.New("没有字段定义")
+	}
+
+	cols := make([]schemaColumn, 0, len(s.Fields))
+	names := make(map[string]bool)
+	for _, field := range s.Fields {
+		if len(strings.TrimSpace(field.Name)) == 0 {
+			return errors.New("字段缺少字段名")
+		}
+		if names[field.Name] {
+			return fmt.Errorf("字段[%s]重复", field.Name)
+		}
+		names[field.Name] = true
+		fcols, err := expandSchemaField(field, field.Name)
+		if err != nil {
+			return err
+		}
+		cols = append(cols, fcols...)
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName(f.GetSheetName(0), sheet); err != nil {
+		return err
+	}
+	axis := func(i, j int) string {
+		name, _ := excelize.CoordinatesToCellName(ternary(vertical, i, j)+1, ternary(vertical, j, i)+1)
+		return name
+	}
+
+	style, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"DDEBF7"}},
+	})
+	for j, col := range cols {
+		for i, v := range headLines(col.name, col.typ, col.mode, col.desc) {
+			if len(v) > 0 {
+				f.SetCellStr(sheet, axis(i, j), v)
+			}
+		}
+		if len(col.comment) > 0 {
+			err := f.AddComment(sheet, excelize.Comment{
+				Cell:      axis(DescLine-1, j),
+				Author:    "excelparser",
+				Paragraph: []excelize.RichTextRun{{Text: col.comment}},
+			})
+			if err != nil {
+				return fmt.Errorf("字段[%s]批注错误: %v", col.name, err)
+			}
+		}
+		if dv := typeValidation(parseType(col.typ), col.name, col.prompt); dv != nil {
+			dv.Sqref = valueSqref(j, vertical)
+			if err := f.AddDataValidation(sheet, dv); err != nil {
+				return fmt.Errorf("字段[%s]数据验证错误: %v", col.name, err)
+			}
+		}
+	}
+	f.SetCellStyle(sheet, "A1", axis(HeadLineNum-1, len(cols)-1), style)
+
+	// 合并数组/map 元素列的字段名单元格(与模板一致，eg.: E1:G1)
+	for j := 0; j < len(cols); {
+		k := j
+		for k < len(cols) && cols[k].element && len(cols[k].name) == 0 {
+			k++
+		}
+		if k-j > 1 {
+			if err := f.MergeCell(sheet, axis(NameLine-1, j), axis(NameLine-1, k-1)); err != nil {
+				return err
+			}
+		}
+		j = max(k, j+1)
+	}
+
+	// 冻结表头
+	panes := &excelize.Panes{Freeze: true, YSplit: HeadLineNum, TopLeftCell: axis(HeadLineNum, 0), ActivePane: "bottomLeft"}
+	if vertical {
+		panes = &excelize.Panes{Freeze: true, XSplit: HeadLineNum, TopLeftCell: axis(HeadLineNum, 0), ActivePane: "topRight"}
+	}
+	if err := f.SetPanes(sheet, panes); err != nil {
+		return err
+	}
+	if err := f.SaveAs(out); err != nil {
+		return err
+	}
+
+	// 按配置表检查表头，表头有错误时删除生成的文件
+	x, err := LoadXlsx(out)
+	if err == nil && len(x.Errors) > 0 {
+		err = errors.New(strings.Join(x.Errors, "\n"))
+	}
+	if err != nil {
+		os.Remove(out)
+		return fmt.Errorf("表头检查失败:\n%v", err)
+	}
+	return nil
+}
+
+// 展开字段为表头列
+// rname 为表头中的字段名(子字段为 parent.x，结构体元素为 parent[])
+func expandSchemaField(field *SchemaField, rname string) ([]schemaColumn, error) {
+	name := strings.TrimSpace(field.Name)
+	if len(name) > 0 && !TypeNameRe.MatchString(name) {
+		return nil, fmt.Errorf("字段名[%s]不合法", name)
+	}
+	typ := strings.TrimSpace(field.Type)
+	if len(typ) == 0 {
+		return nil, fmt.Errorf("字段[%s]缺少类型", rname)
+	}
+
+	// 描述为空时使用字段名(表头必须有 4 行)
+	desc := field.Desc
+	if len(desc) == 0 {
+		desc = name
+	}
+	cols := []schemaColumn{{name: rname, typ: typ, mode: field.Mode, desc: desc, comment: field.Comment, prompt: fieldPrompt(field.Desc, field.Comment)}}
+	t := parseType(typ)
+	switch {
+	case field.Size < 0:
+		return nil, fmt.Errorf("字段[%s]的元素个数(size)不能小于0", rname)
+	case field.Size > 0 && t.Kind != TArray && t.Kind != TMap:
+		return nil, fmt.Errorf("字段[%s]不是数组或者 map，不能指定元素个数(size)", rname)
+	case len(field.Fields) > 0 && field.Size == 0 && t.Kind != TStruct:
+		return nil, fmt.Errorf("字段[%s]不是结构体，不能指定子字段(数组/map 需要指定元素个数 size)", rname)
+	}
+
+	// 元素字段(结构体元素的字段名为 parent[])
+	element := func(etyp string) ([]schemaColumn, error) {
+		ef := &SchemaField{Type: etyp, Fields: field.Fields}
+		ename := ""
+		if len(field.Fields) > 0 {
+			ename = rname + "[]"
+		}
+		ecols, err := expandSchemaField(ef, ename)
+		if err != nil {
+			return nil, err
+		}
+		ecols[0].element = true
+		return ecols, nil
+	}
+
+	switch {
+	case t.Kind == TArray && field.Size > 0:
+		if t.Cap > 0 && field.Size != t.Cap {
+			return nil, fmt.Errorf("字段[%s]是定长数组，元素个数(size)必须为 %d", rname, t.Cap)
+		}
+		etyp := ArrayRe.FindStringSubmatch(typ)[2]
+		for range field.Size {
+			ecols, err := element(etyp)
+			if err != nil {
+				return nil, err
+			}
+			cols = append(cols, ecols...)
+		}
+	case t.Kind == TMap && field.Size > 0:
+		s := MapRe.FindStringSubmatch(typ)
+		for range field.Size {
+			cols = append(cols, schemaColumn{typ: s[1], element: true})
+			ecols, err := element(s[2])
+			if err != nil {
+				return nil, err
+			}
+			cols = append(cols, ecols...)
+		}
+	case t.Kind == TStruct && len(field.Fields) > 0:
+		names := make(map[string]bool)
+		for _, sub := range field.Fields {
+			if len(strings.TrimSpace(sub.Name)) == 0 {
+				return nil, fmt.Errorf("字段[%s]的子字段缺少字段名", rname)
+			}
+			if names[sub.Name] {
+				return nil, fmt.Errorf("字段[%s.%s]重复", rname, sub.Name)
+			}
+			names[sub.Name] = true
+			scols, err := expandSchemaField(sub, rname+"."+strings.TrimSpace(sub.Name))
+			if err != nil {
+				return nil, err
+			}
+			cols = append(cols, scols...)
+		}
+	}
+	return cols, nil
+}
@@ -0,0 +1,374 @@
+// 由 go/c# 类型定义读取表结构(new 命令)
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// 源码中的类型定义
+type srcType struct {
+	name   string
+	fields []*SchemaField
+}
+
+// 源码类型转换为表头类型
+type srcTypeConv struct {
+	types    map[string]*srcType
+	visiting map[string]bool
+}
+
+// 源码中定义的类型转换为匿名结构体(单元格结构体)
+func (c *srcTypeConv) structType(name string) string {
+	parts := make([]string, 0, len(c.types[name].fields))
+	for _, f := range c.types[name].fields {
+		parts = append(parts, f.Name+"="+f.Type)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// 字段名首字母小写(与导出代码的首字母大写对应)
+func srcFieldName(name string) string {
+	r := []rune(name)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
+
+// 选择作为配置表的类型
+func findSrcType(types []*srcType, typeName string) (*srcType, error) {
+	if len(types) == 0 {
+		return nil, errors.New("没有找到类型定义")
+	}
+	if len(typeName) == 0 {
+		return types[0], nil
+	}
+	for _, t := range types {
+		if t.name == typeName {
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("类型[%s]不存在", typeName)
+}
+
+//#region MARK: go
+
+// 读取 go struct 定义，导出字段为配置表字段
+// 字段名使用 json tag(没有时首字母小写)，字段注释为描述
+func readGoSchema(path string, data []byte, typeName string) (*TableSchema, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, data, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("go 文件解析失败: %v", err)
+	}
+
+	// 收集所有结构体
+	specs := make(map[string]*ast.StructType)
+	order := make([]string, 0)
+	ast.Inspect(file, func(n ast.Node) bool {
+		if ts, ok := n.(*ast.TypeSpec); ok {
+			if st, ok := ts.Type.(*ast.StructType); ok {
+				specs[ts.Name.Name] = st
+				order = append(order, ts.Name.Name)
+			}
+		}
+		return true
+	})
+
+	c := &srcTypeConv{types: make(map[string]*srcType), visiting: make(map[string]bool)}
+	var goType func(expr ast.Expr) (string, error)
+	goFields := func(st *ast.StructType) ([]*SchemaField, error) {
+		fields := make([]*SchemaField, 0, len(st.Fields.List))
+		for _, field := range st.Fields.List {
+			typ, err := goType(field.Type)
+			if err != nil {
+				return nil, err
+			}
+			desc := strings.TrimSpace(field.Doc.Text())
+			if len(desc) == 0 {
+				desc = strings.TrimSpace(field.Comment.Text())
+			}
+			desc = strings.Join(strings.Fields(desc), " ")
+			for _, ident := range field.Names {
+				if !ident.IsExported() {
+					continue
+				}
+				name := srcFieldName(ident.Name)
+				if field.Tag != nil {
+					tag := reflect.StructTag(strings.Trim(field.Tag.Value, "`"))
+					if jname, _, _ := strings.Cut(tag.Get("json"), ","); jname == "-" {
+						continue
+					} else if len(jname) > 0 {
+						name = jname
+					}
+				}
+				fields = append(fields, &SchemaField{Name: name, Type: typ, Desc: desc})
+			}
+		}
+		return fields, nil
+	}
+	goType = func(expr ast.Expr) (string, error) {
+		switch e := expr.(type) {
+		case *ast.Ident:
+			switch e.Name {
+			case "int", "int8", "int16", "int32", "int64", "rune":
+				return "int", nil
+			case "uint", "uint8", "uint16", "uint32", "uint64", "byte":
+				return "uint", nil
+			case "float32", "float64":
+				return "float", nil
+			case "bool", "string", "any":
+				return e.Name, nil
+			}
+			if st, ok := specs[e.Name]; ok {
+				if _, ok := c.types[e.Name]; !ok {
+					if c.visiting[e.Name] {
+						return "", fmt.Errorf("类型[%s]循环引用", e.Name)
+					}
+					c.visiting[e.Name] = true
+					fields, err := goFields(st)
+					delete(c.visiting, e.Name)
+					if err != nil {
+						return "", err
+					}
+					c.types[e.Name] = &srcType{name: e.Name, fields: fields}
+				}
+				return c.structType(e.Name), nil
+			}
+			// 共享类型
+			return e.Name, nil
+		case *ast.StarExpr:
+			return goType(e.X)
+		case *ast.InterfaceType:
+			return "any", nil
+		case *ast.ArrayType:
+			elem, err := goType(e.Elt)
+			if err != nil {
+				return "", err
+			}
+			if e.Len == nil {
+				return "[]" + elem, nil
+			}
+			if lit, ok := e.Len.(*ast.BasicLit); ok && lit.Kind == token.INT {
+				return "[" + lit.Value + "]" + elem, nil
+			}
+		case *ast.MapType:
+			key, err := goType(e.Key)
+			if err != nil {
+				return "", err
+			}
+			val, err := goType(e.Value)
+			if err != nil {
+				return "", err
+			}
+			return "map[" + key + "]" + val, nil
+		case *ast.StructType:
+			fields, err := goFields(e)
+			if err != nil {
+				return "", err
+			}
+			parts := make([]string, 0, len(fields))
+			for _, f := range fields {
+				parts = append(parts, f.Name+"="+f.Type)
+			}
+			return "{" + strings.Join(parts, ",") + "}", nil
+		}
+		return "", fmt.Errorf("不支持的类型[%s]", data[expr.Pos()-1:expr.End()-1])
+	}
+
+	types := make([]*srcType, 0, len(order))
+	for _, name := range order {
+		types = append(types, &srcType{name: name})
+	}
+	t, err := findSrcType(types, typeName)
+	if err != nil {
+		return nil, err
+	}
+	c.visiting[t.name] = true
+	fields, err := goFields(specs[t.name])
+	if err != nil {
+		return nil, fmt.Errorf("类型[%s]: %v", t.name, err)
+	}
+	return &TableSchema{Fields: fields}, nil
+}
+
+//#endregion
+
+//#region MARK: c#
+
+var (
+	csTypeRe   = regexp.MustCompile(`\b(?:class|struct|record)\s+(\w+)[^{;]*\{`)
+	csFieldRe  = regexp.MustCompile(`^public\s+(?:(?:readonly|required|virtual|override|new)\s+)*(.+?)\s+(\w+)\s*(?:[;={]|$)`)
+	csXmlTagRe = regexp.MustCompile(`<[^>]*>`)
+)
+
+// 读取 c# class/struct 定义，public 字段和属性为配置表字段
+// 字段名首字母小写，/// <summary> 或 // 注释为描述
+func readCSharpSchema(data []byte, typeName string) (*TableSchema, error) {
+	text := string(data)
+	types := make([]*srcType, 0)
+	bodies := make(map[string]string)
+	for _, m := range csTypeRe.FindAllStringSubmatchIndex(text, -1) {
+		name := text[m[2]:m[3]]
+		start := m[1]
+		depth := 1
+		end := start
+		for ; end < len(text) && depth > 0; end++ {
+			switch text[end] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+		}
+		if depth > 0 {
+			return nil, fmt.Errorf("类型[%s]的大括号不匹配", name)
+		}
+		types = append(types, &srcType{name: name})
+		bodies[name] = text[start : end-1]
+	}
+
+	c := &srcTypeConv{types: make(map[string]*srcType), visiting: make(map[string]bool)}
+	var csType func(typ string) (string, error)
+	csFields := func(name string) ([]*SchemaField, error) {
+		fields := make([]*SchemaField, 0)
+		var comments []string
+		depth := 0
+		for _, line := range strings.Split(bodies[name], "\n") {
+			line = strings.TrimSpace(line)
+			lineDepth := depth
+			depth += strings.Count(line, "{") - strings.Count(line, "}")
+			if lineDepth > 0 {
+				continue
+			}
+			switch {
+			case strings.HasPrefix(line, "//"):
+				comment := strings.TrimSpace(csXmlTagRe.ReplaceAllString(strings.TrimLeft(line, "/"), ""))
+				if len(comment) > 0 {
+					comments = append(comments, comment)
+				}
+				continue
+			case strings.HasPrefix(line, "["), len(line) == 0:
+				// 特性
+				continue
+			}
+
+			desc := strings.Join(comments, " ")
+			comments = nil
+			code, comment, _ := strings.Cut(line, "//")
+			if len(desc) == 0 {
+				desc = strings.TrimSpace(comment)
+			}
+			m := csFieldRe.FindStringSubmatch(strings.TrimSpace(code))
+			if m == nil || strings.ContainsAny(m[1], "()") || strings.Contains(code, "=>") {
+				continue
+			}
+			switch strings.Fields(m[1])[0] {
+			case "static", "const", "class", "struct", "record", "enum", "void", "event", "delegate":
+				continue
+			}
+			typ, err := csType(m[1])
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, &SchemaField{Name: srcFieldName(m[2]), Type: typ, Desc: desc})
+		}
+		return fields, nil
+	}
+	csType = func(typ string) (string, error) {
+		typ = strings.TrimSuffix(strings.TrimSpace(typ), "?")
+		switch typ {
+		case "int", "long", "short", "sbyte", "Int16", "Int32", "Int64", "SByte":
+			return "int", nil
+		case "uint", "ulong", "ushort", "byte", "UInt16", "UInt32", "UInt64", "Byte":
+			return "uint", nil
+		case "float", "double", "decimal", "Single", "Double", "Decimal":
+			return "float", nil
+		case "bool", "Boolean":
+			return "bool", nil
+		case "string", "String":
+			return "string", nil
+		case "object", "Object":
+			return "any", nil
+		}
+		if elem, ok := strings.CutSuffix(typ, "[]"); ok {
+			elem, err := csType(elem)
+			return "[]" + elem, err
+		}
+		if i := strings.IndexByte(typ, '<'); i > 0 && strings.HasSuffix(typ, ">") {
+			args := splitGenericArgs(typ[i+1 : len(typ)-1])
+			switch generic := typ[:i]; {
+			case len(args) == 1 && (strings.HasSuffix(generic, "List") || generic == "IEnumerable" || generic == "ICollection" || generic == "IReadOnlyCollection"):
+				elem, err := csType(args[0])
+				return "[]" + elem, err
+			case len(args) == 2 && strings.HasSuffix(generic, "Dictionary"):
+				key, err := csType(args[0])
+				if err != nil {
+					return "", err
+				}
+				val, err := csType(args[1])
+				return "map[" + key + "]" + val, err
+			}
+			return "", fmt.Errorf("不支持的类型[%s]", typ)
+		}
+		if _, ok := bodies[typ]; ok {
+			if _, ok := c.types[typ]; !ok {
+				if c.visiting[typ] {
+					return "", fmt.Errorf("类型[%s]循环引用", typ)
+				}
+				c.visiting[typ] = true
+				fields, err := csFields(typ)
+				delete(c.visiting, typ)
+				if err != nil {
+					return "", err
+				}
+				c.types[typ] = &srcType{name: typ, fields: fields}
+			}
+			return c.structType(typ), nil
+		}
+		if !TypeNameRe.MatchString(typ) {
+			return "", fmt.Errorf("不支持的类型[%s]", typ)
+		}
+		// 共享类型
+		return typ, nil
+	}
+
+	t, err := findSrcType(types, typeName)
+	if err != nil {
+		return nil, err
+	}
+	c.visiting[t.name] = true
+	fields, err := csFields(t.name)
+	if err != nil {
+		return nil, fmt.Errorf("类型[%s]: %v", t.name, err)
+	}
+	return &TableSchema{Fields: fields}, nil
+}
+
+// 拆分泛型参数(eg.: int, List<int> -> [int, List<int>])
+func splitGenericArgs(s string) []string {
+	var args []string
+	depth, start := 0, 0
+	for i, ch := range s {
+		switch ch {
+		case '<':
+			depth++
+		case '>':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(args, strings.TrimSpace(s[start:]))
+}
+
+//#endregion
@@ -0,0 +1,273 @@
+// 配置数据工作表读取(xlsx、ods、csv/tsv)
+
+package core
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// 支持的输入文件扩展名
+var SheetExts = []string{".xlsx", ".ods", ".csv", ".tsv"}
+
+// csv/tsv 附属文件扩展名(eg.: D-道具@item.csv 的附属文件为 D-道具@item.meta.yaml)
+const SheetMetaExt = ".meta.yaml"
+
+var errNoDataSheet = errors.New("data/vdata sheet 不存在")
+
+// 单元格批注
+type CellComment struct {
+	Cell string // 单元格(eg.: B4)
+	Text string // 批注文本(多个段落拼接)
+}
+
+// 合并单元格
+type MergeRange struct {
+	Start string // 起始单元格(eg.: E1)
+	End   string // 结束单元格(eg.: G1)
+}
+
+// 配置数据工作表，不同的输入格式实现该接口
+// 单元格的值为格式化之后的文本，行尾的空单元格可以省略
+type SheetReader interface {
+	Name() string                      // 工作表名
+	Vertical() bool                    // 是否纵向表
+	Lines() ([][]string, error)        // 所有行(纵向表为列)
+	Comments() ([]CellComment, error)  // 单元格批注
+	MergeCells() ([]MergeRange, error) // 合并单元格
+	Close() error
+}
+
+// 支持公式的工作表(--formula)
+type formulaSheet interface {
+	GetCellFormula(axis string) (string, error)
+	CalcCellValue(axis string) (string, error)
+}
+
+// 是否是配置表输入文件(忽略 ~$ 开头的临时文件)
+// xlsx 以外的格式只识别带有导出名的文件(eg.: D-道具@item.csv)，配置目录中的其他 csv 等辅助文件不导出
+func isSheetFile(name string) bool {
+	if len(name) == 0 || strings.ContainsRune("~$", rune(name[0])) {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	if !slices.Contains(SheetExts, ext) {
+		return false
+	}
+	return ext == ".xlsx" || strings.Contains(getFileName(name), "@")
+}
+
+// 打开配置表文件的配置数据工作表
+func OpenSheet(path string) (SheetReader, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return openCsvSheet(path, ',')
+	case ".tsv":
+		return openCsvSheet(path, '\t')
+	case ".ods":
+		return openOdsSheet(path)
+	default:
+		return openXlsxSheet(path)
+	}
+}
+
+// 配置表文件最后修改时间(毫秒)，csv/tsv 包含附属文件的修改时间
+func sheetModTime(path string, info os.FileInfo) uint64 {
+	modTime := uint64(info.ModTime().UnixNano() / 1000000)
+	if meta, err := os.Stat(sheetMetaPath(path)); err == nil {
+		modTime = max(modTime, uint64(meta.ModTime().UnixNano()/1000000))
+	}
+	return modTime
+}
+
+//#region MARK: xlsx
+
+type xlsxSheet struct {
+	file     *excelize.File
+	name     string
+	vertical bool
+	lines    [][]string
+}
+
+func openXlsxSheet(path string) (*xlsxSheet, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("xlsx文件打开失败: %v", err)
+	}
+	name, vertical := findDataSheet(f)
+	if len(name) == 0 {
+		f.Close()
+		return nil, errNoDataSheet
+	}
+	return &xlsxSheet{file: f, name: name, vertical: vertical}, nil
+}
+
+func (s *xlsxSheet) Name() string   { return s.name }
+func (s *xlsxSheet) Vertical() bool { return s.vertical }
+func (s *xlsxSheet) Close() error   { return s.file.Close() }
+
+func (s *xlsxSheet) Lines() ([][]string, error) {
+	if s.lines != nil {
+		return s.lines, nil
+	}
+	var err error
+	if s.vertical {
+		s.lines, err = s.file.GetCols(s.name)
+	} else {
+		s.lines, err = s.file.GetRows(s.name)
+	}
+	return s.lines, err
+}
+
+func (s *xlsxSheet) Comments() ([]CellComment, error) {
+	comments, err := s.file.GetComments(s.name)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]CellComment, 0, len(comments))
+	for _, c := range comments {
+		results = append(results, CellComment{c.Cell, commentText(c)})
+	}
+	return results, nil
+}
+
+func (s *xlsxSheet) MergeCells() ([]MergeRange, error) {
+	mergeCells, err := s.file.GetMergeCells(s.name)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]MergeRange, 0, len(mergeCells))
+	for _, mc := range mergeCells {
+		results = append(results, MergeRange{mc.GetStartAxis(), mc.GetEndAxis()})
+	}
+	return results, nil
+}
+
+func (s *xlsxSheet) GetCellFormula(axis string) (string, error) {
+	return s.file.GetCellFormula(s.name, axis)
+}
+
+func (s *xlsxSheet) CalcCellValue(axis string) (string, error) {
+	return s.file.CalcCellValue(s.name, axis)
+}
+
+//#endregion
+
+//#region MARK: csv/tsv
+
+// csv/tsv 附属文件内容，csv/tsv 本身只能保存单元格的值
+type sheetMeta struct {
+	Vertical bool              `yaml:"vertical"` // 纵向表(表头在前 4 列)
+	Comments map[string]string `yaml:"comments"` // 单元格 -> 批注
+	Merges   []string          `yaml:"merges"`   // 合并单元格(eg.: E1:G1)
+}
+
+// 文件内容与工作表一致(第 n 行对应工作表的第 n 行)，编码为 UTF-8(可以带 BOM)
+// tsv 不支持引号转义，单元格中不能有制表符和换行
+type csvSheet struct {
+	meta  sheetMeta
+	lines [][]string
+}
+
+func sheetMetaPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + SheetMetaExt
+}
+
+func openCsvSheet(path string, comma rune) (*csvSheet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("文件读取失败: %v", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	s := &csvSheet{}
+	if meta, err := os.ReadFile(sheetMetaPath(path)); err == nil {
+		if err := yaml.Unmarshal(meta, &s.meta); err != nil {
+			return nil, fmt.Errorf("附属文件[%s]格式错误: %v", filepath.Base(sheetMetaPath(path)), err)
+		}
+	}
+
+	var rows [][]string
+	if comma == '\t' {
+		text := strings.ReplaceAll(string(data), "\r\n", "\n")
+		for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+			rows = append(rows, strings.Split(line, "\t"))
+		}
+	} else {
+		r := csv.NewReader(bytes.NewReader(data))
+		r.Comma = comma
+		r.FieldsPerRecord = -1
+		if rows, err = r.ReadAll(); err != nil {
+			return nil, fmt.Errorf("csv 格式错误: %v", err)
+		}
+	}
+	for i := range rows {
+		rows[i] = trimLine(rows[i])
+	}
+
+	s.lines = rows
+	if s.meta.Vertical {
+		s.lines = transposeLines(rows)
+	}
+	// 去掉末尾的空行(与 xlsx 一致)
+	for len(s.lines) > 0 && len(s.lines[len(s.lines)-1]) == 0 {
+		s.lines = s.lines[:len(s.lines)-1]
+	}
+	return s, nil
+}
+
+// 按行读取的单元格转为按列读取(纵向表)
+func transposeLines(rows [][]string) [][]string {
+	var cols [][]string
+	for i, row := range rows {
+		for j, v := range row {
+			if len(v) == 0 {
+				continue
+			}
+			for len(cols) <= j {
+				cols = append(cols, nil)
+			}
+			for len(cols[j]) < i {
+				cols[j] = append(cols[j], "")
+			}
+			cols[j] = append(cols[j], v)
+		}
+	}
+	return cols
+}
+
+func (s *csvSheet) Name() string               { return ternary(s.meta.Vertical, "vdata", "data") }
+func (s *csvSheet) Vertical() bool             { return s.meta.Vertical }
+func (s *csvSheet) Lines() ([][]string, error) { return s.lines, nil }
+func (s *csvSheet) Close() error               { return nil }
+
+func (s *csvSheet) Comments() ([]CellComment, error) {
+	results := make([]CellComment, 0, len(s.meta.Comments))
+	for _, cell := range sortedAxes(s.meta.Comments) {
+		results = append(results, CellComment{cell, s.meta.Comments[cell]})
+	}
+	return results, nil
+}
+
+func (s *csvSheet) MergeCells() ([]MergeRange, error) {
+	results := make([]MergeRange, 0, len(s.meta.Merges))
+	for _, ref := range s.meta.Merges {
+		start, end, ok := strings.Cut(ref, ":")
+		if !ok {
+			return nil, fmt.Errorf("合并单元格[%s]格式错误", ref)
+		}
+		results = append(results, MergeRange{start, end})
+	}
+	return results, nil
+}
+
+//#endregion
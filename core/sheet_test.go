@@ -0,0 +1,143 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsSheetFile(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"D-道具@item.xlsx", true},
+		{"D-道具.xlsx", true},
+		{"D-道具@item.CSV", true},
+		{"X-系统@system.tsv", true},
+		{"helper.csv", false},
+		{"~$D-道具@item.xlsx", false},
+		{"$D-道具@item.csv", false},
+		{"D-道具@item.meta.yaml", false},
+		{"D-道具@item.xls", false},
+	}
+	for _, tt := range tests {
+		if got := isSheetFile(tt.name); got != tt.want {
+			t.Errorf("isSheetFile(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestOpenCsvSheet(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		meta     string
+		vertical bool
+		lines    string // 行之间使用 / 分隔，单元格之间使用 , 分隔
+		comments string
+		merges   string
+		err      string
+	}{
+		{
+			name:  "D-道具@item.csv",
+			data:  "\xef\xbb\xbfid,name,,\r\nint,string\r\n\"1,2\",\"a\"\"b\"\n\n,\n",
+			lines: "id,name/int,string/1,2,a\"b",
+		},
+		{
+			name:  "D-道具@item.tsv",
+			data:  "id\tname\r\nint\t\"s\"\n",
+			lines: "id,name/int,\"s\"",
+		},
+		{
+			name:     "X-系统@system.csv",
+			data:     "a,int,,名称,1\nb,string,,描述,x\nc,,,,\n",
+			meta:     "vertical: true\ncomments:\n  D2: 批注2\n  D1: 批注1\nmerges: [E1:G1]\n",
+			vertical: true,
+			lines:    "a,b,c/int,string//名称,描述/1,x",
+			comments: "D1=批注1,D2=批注2",
+			merges:   "E1-G1",
+		},
+		{
+			name: "D-道具@item.csv",
+			data: "id\n",
+			meta: "vertical: [\n",
+			err:  "附属文件[D-道具@item.meta.yaml]格式错误",
+		},
+		{
+			name: "D-道具@item.csv",
+			data: "\"id\n",
+			err:  "csv 格式错误",
+		},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		path := filepath.Join(dir, tt.name)
+		os.WriteFile(path, []byte(tt.data), 0o666)
+		if len(tt.meta) > 0 {
+			os.WriteFile(sheetMetaPath(path), []byte(tt.meta), 0o666)
+		}
+
+		sheet, err := OpenSheet(path)
+		if len(tt.err) > 0 {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+
+		lines, _ := sheet.Lines()
+		rows := make([]string, 0, len(lines))
+		for _, line := range lines {
+			rows = append(rows, strings.Join(line, ","))
+		}
+		var comments, merges []string
+		cs, _ := sheet.Comments()
+		for _, c := range cs {
+			comments = append(comments, c.Cell+"="+c.Text)
+		}
+		ms, _ := sheet.MergeCells()
+		for _, m := range ms {
+			merges = append(merges, m.Start+"-"+m.End)
+		}
+		name := ternary(tt.vertical, "vdata", "data")
+		if sheet.Name() != name || sheet.Vertical() != tt.vertical {
+			t.Errorf("%s: sheet %s vertical %v", tt.name, sheet.Name(), sheet.Vertical())
+		}
+		for _, v := range [][3]string{
+			{"lines", strings.Join(rows, "/"), tt.lines},
+			{"comments", strings.Join(comments, ","), tt.comments},
+			{"merges", strings.Join(merges, ","), tt.merges},
+		} {
+			if v[1] != v[2] {
+				t.Errorf("%s: %s = %q, want %q", tt.name, v[0], v[1], v[2])
+			}
+		}
+	}
+}
+
+func TestLoadCsvXlsx(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "X-系统@system.csv")
+	os.WriteFile(path, []byte("max_level,int,,最大等级,100\nname,string,,名称,系统\n"), 0o666)
+	os.WriteFile(sheetMetaPath(path), []byte("vertical: true\ncomments:\n  D1: 玩家最大等级\n"), 0o666)
+
+	x, err := LoadXlsx(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !x.Vertical || x.OutName != "system" || len(x.Errors) > 0 {
+		t.Fatalf("x = %s vertical %v errors %v", x.OutName, x.Vertical, x.Errors)
+	}
+	if len(x.Rows) != 1 || strings.Join(x.Rows[0], ",") != "100,系统" {
+		t.Errorf("rows = %q", x.Rows)
+	}
+	if x.Comments[0] != "玩家最大等级" {
+		t.Errorf("comments = %v", x.Comments)
+	}
+}
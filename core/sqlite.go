@@ -0,0 +1,326 @@
+// sqlite 导出
+
+package core
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	_ "modernc.org/sqlite"
+)
+
+// sqlite 表数据
+type sqliteTable struct {
+	Name         string          // 表名(导出名)
+	Source       string          // 来源文件
+	Mode         string          // 导出目标
+	Vertical     bool            // 纵向表
+	Columns      []*sqliteColumn // 列定义
+	Rows         [][]any         // 行数据
+	LastModified uint64          // 来源文件最后修改时间
+}
+
+type sqliteColumn struct {
+	Name string // 列名
+	Type string // sqlite 列类型
+	Raw  string // 配置表中的字段类型
+	Desc string // 字段描述
+}
+
+var (
+	sqliteMutex  sync.Mutex
+	sqliteTables = make(map[string][]*sqliteTable) // 输出目录 -> 待写入的表
+)
+
+// sqlite 导出，每个配置表对应一张表
+// 基础类型字段为对应类型的列，复合类型字段(数组、map、结构体、json)保存为 json 文本
+func (s *SqliteFormater) formatRows() {
+	s.line = 0
+	s.SqlTable = nil
+
+	fields := make([]*Field, 0, len(s.RootField.Vals))
+	tbl := &sqliteTable{
+		Name:         s.OutName,
+		Source:       s.Name,
+		Mode:         s.mode,
+		Vertical:     s.Vertical,
+		LastModified: s.LastModified,
+	}
+	for _, f := range s.RootField.Vals {
+		if f.isHitMode(s.mode) {
+			fields = append(fields, f)
+			col := &sqliteColumn{Name: f.Name, Type: sqliteColumnType(f.Type), Desc: f.Desc}
+			if f.Index < len(s.Types) {
+				col.Raw = strings.TrimSpace(s.Types[f.Index])
+			}
+			tbl.Columns = append(tbl.Columns, col)
+		}
+	}
+
+	b := &treeBuilder{Xlsx: s.Xlsx, mode: s.mode}
+	root := b.buildRows()
+	s.line = b.line
+
+	rows := []*valueNode{root}
+	if !s.Vertical {
+		rows = root.Vals
+	}
+	for _, r := range rows {
+		vals := make([]any, len(fields))
+		for i := range fields {
+			if i < len(r.Vals) {
+				vals[i] = sqliteValue(r.Vals[i])
+			}
+		}
+		tbl.Rows = append(tbl.Rows, vals)
+	}
+	s.SqlTable = tbl
+}
+
+func sqliteColumnType(t *Type) string {
+	switch t.Kind {
+	case TInt, TUint, TBool:
+		return "INTEGER"
+	case TFloat:
+		return "REAL"
+	case TAny:
+		return ""
+	default:
+		return "TEXT"
+	}
+}
+
+func sqliteValue(n *valueNode) any {
+	switch n.Kind {
+	case NodeInt:
+		if v, err := strconv.ParseInt(n.Val, 10, 64); err == nil {
+			return v
+		}
+		return n.Val
+	case NodeFloat:
+		v, _ := strconv.ParseFloat(n.Val, 64)
+		return v
+	case NodeBool:
+		return ternary(n.Val == "true", 1, 0)
+	case NodeString:
+		return n.Val
+	case NodeList, NodeMap:
+		return n.toJson()
+	default:
+		return nil
+	}
+}
+
+// 添加待写入的表(写入在所有配置表解析完成后进行)
+func addSqliteTable(outdir string, tbl *sqliteTable) {
+	if tbl == nil {
+		return
+	}
+	sqliteMutex.Lock()
+	defer sqliteMutex.Unlock()
+	sqliteTables[outdir] = append(sqliteTables[outdir], tbl)
+}
+
+func resetSqliteTables() {
+	sqliteMutex.Lock()
+	defer sqliteMutex.Unlock()
+	clear(sqliteTables)
+}
+
+// WriteSqliteDB 将本次导出的表写入 outdir 下的 目标名.db
+// 只重建本次导出的表，未修改的配置表保留原有数据
+// 先写入临时文件，数据库内容没有变化时保留原文件(清单中的哈希和版本号不变)
+func WriteSqliteDB(outdir, mode string) error {
+	sqliteMutex.Lock()
+	tables := sqliteTables[outdir]
+	delete(sqliteTables, outdir)
+	sqliteMutex.Unlock()
+	if len(tables) == 0 {
+		return nil
+	}
+	slices.SortFunc(tables, func(a, b *sqliteTable) int { return strings.Compare(a.Name, b.Name) })
+
+	if err := os.MkdirAll(outdir, 0o755); err != nil {
+		return err
+	}
+	dbFile := filepath.Join(outdir, mode+".db")
+	tmpFile := dbFile + ".tmp"
+	os.Remove(tmpFile)
+	old, err := os.ReadFile(dbFile)
+	if err == nil {
+		if err := os.WriteFile(tmpFile, old, 0o666); err != nil {
+			return err
+		}
+	}
+	if err := writeSqliteFile(tmpFile, tables); err != nil {
+		os.Remove(tmpFile)
+		return fmt.Errorf("写入 %s 失败: %v", dbFile, err)
+	}
+
+	if len(old) > 0 {
+		a, err1 := sqliteDigest(dbFile)
+		b, err2 := sqliteDigest(tmpFile)
+		if err1 == nil && err2 == nil && a == b {
+			return os.Remove(tmpFile)
+		}
+	}
+	return os.Rename(tmpFile, dbFile)
+}
+
+func writeSqliteFile(dbFile string, tables []*sqliteTable) error {
+	db, err := sql.Open("sqlite", dbFile)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmts := []string{
+		`CREATE TABLE IF NOT EXISTS "_meta" ("name" TEXT PRIMARY KEY, "source" TEXT, "mode" TEXT, "rows" INTEGER, "last_modified" INTEGER)`,
+		`CREATE TABLE IF NOT EXISTS "_fields" ("tbl" TEXT, "name" TEXT, "type" TEXT, "desc" TEXT, "idx" INTEGER, PRIMARY KEY ("tbl", "name"))`,
+	}
+	for _, stmt := range stmts {
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+
+	for _, tbl := range tables {
+		if err := tbl.write(tx); err != nil {
+			return fmt.Errorf("%s: %v", tbl.Source, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// 数据库内容摘要(表结构和数据，与行的存储顺序无关)
+// sqlite 文件头中的修改计数等每次写入都会变化，不能直接比较文件内容
+func sqliteDigest(dbFile string) (string, error) {
+	db, err := sql.Open("sqlite", dbFile)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	schema, err := sqliteQueryText(db, `SELECT type, name, sql FROM sqlite_master`)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, s := range schema {
+		fmt.Fprintln(h, s)
+	}
+	names, err := sqliteQueryText(db, `SELECT name FROM sqlite_master WHERE type = 'table'`)
+	if err != nil {
+		return "", err
+	}
+	for _, name := range names {
+		rows, err := sqliteQueryText(db, "SELECT * FROM "+sqliteIdent(name))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintln(h, name, len(rows))
+		for _, r := range rows {
+			fmt.Fprintln(h, r)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// 查询结果每行转为文本，按文本排序
+func sqliteQueryText(db *sql.DB, query string) ([]string, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]string, 0)
+	vals := make([]any, len(cols))
+	ptrs := make([]any, len(cols))
+	for i := range vals {
+		ptrs[i] = &vals[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		if len(cols) == 1 {
+			lines = append(lines, fmt.Sprint(vals[0]))
+		} else {
+			lines = append(lines, fmt.Sprintf("%#v", vals))
+		}
+	}
+	slices.Sort(lines)
+	return lines, rows.Err()
+}
+
+func (tbl *sqliteTable) write(tx *sql.Tx) error {
+	name := sqliteIdent(tbl.Name)
+	if _, err := tx.Exec("DROP TABLE IF EXISTS " + name); err != nil {
+		return err
+	}
+
+	defs := make([]string, 0, len(tbl.Columns))
+	marks := make([]string, 0, len(tbl.Columns))
+	for i, col := range tbl.Columns {
+		def := sqliteIdent(col.Name)
+		if len(col.Type) > 0 {
+			def += " " + col.Type
+		}
+		if i == 0 && !tbl.Vertical {
+			def += " PRIMARY KEY"
+		}
+		defs = append(defs, def)
+		marks = append(marks, "?")
+	}
+	if _, err := tx.Exec(fmt.Sprintf("CREATE TABLE %s (%s)", name, strings.Join(defs, ", "))); err != nil {
+		return err
+	}
+
+	insert, err := tx.Prepare(fmt.Sprintf("INSERT INTO %s VALUES (%s)", name, strings.Join(marks, ", ")))
+	if err != nil {
+		return err
+	}
+	defer insert.Close()
+	for _, row := range tbl.Rows {
+		if _, err := insert.Exec(row...); err != nil {
+			return err
+		}
+	}
+
+	// 不写入导出时间，表内容不变时数据库内容不变(清单中的哈希保持不变)
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO "_meta" ("name", "source", "mode", "rows", "last_modified") VALUES (?, ?, ?, ?, ?)`,
+		tbl.Name, tbl.Source, tbl.Mode, len(tbl.Rows), tbl.LastModified); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM "_fields" WHERE "tbl" = ?`, tbl.Name); err != nil {
+		return err
+	}
+	for i, col := range tbl.Columns {
+		if _, err := tx.Exec(`INSERT INTO "_fields" VALUES (?, ?, ?, ?, ?)`, tbl.Name, col.Name, col.Raw, col.Desc, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sqliteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,126 @@
+// 导出目标
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// 自定义导出目标参数，格式: 名称:格式列表[:输出目录]，可重复指定
+// eg.: --target=battle:lua,json --target=gm:json:./gm/config
+type TargetFlagSlice []Target
+
+func (s *TargetFlagSlice) String() string {
+	strs := make([]string, 0, len(*s))
+	for _, t := range *s {
+		str := t.Name + ":" + strings.Join(t.Formats, ",")
+		if len(t.Output) > 0 {
+			str += ":" + t.Output
+		}
+		strs = append(strs, str)
+	}
+	return strings.Join(strs, " ")
+}
+
+func (s *TargetFlagSlice) Set(value string) error {
+	parts := strings.SplitN(value, ":", 3)
+	if len(parts) < 2 || len(parts[0]) == 0 || len(parts[1]) == 0 {
+		return errors.New("invalid target, eg: battle:lua,json[:./out/battle]")
+	}
+	if err := checkTargetName(parts[0]); err != nil {
+		return err
+	}
+	for _, t := range *s {
+		if t.Name == parts[0] {
+			return fmt.Errorf("duplicate target [%s]", parts[0])
+		}
+	}
+	t := Target{Name: parts[0], Formats: strings.Split(parts[1], ",")}
+	if len(parts) == 3 {
+		t.Output = parts[2]
+	}
+	*s = append(*s, t)
+	return nil
+}
+
+// 所有导出目标(server、client 及自定义目标)
+func ExportTargets() []Target {
+	targets := make([]Target, 0, len(GFlags.Targets)+2)
+	targets = append(targets, Target{Name: "server", Formats: GFlags.Server, Output: GFlags.Outputs["server"]})
+	targets = append(targets, Target{Name: "client", Formats: GFlags.Client, Output: GFlags.Outputs["client"]})
+	targets = append(targets, GFlags.Targets...)
+	return targets
+}
+
+func FindTarget(name string) *Target {
+	for _, t := range ExportTargets() {
+		if t.Name == name {
+			return &t
+		}
+	}
+	return nil
+}
+
+// 导出目录
+// linux: out/server/json/
+// windows: out\server\json\
+func (t *Target) outDir(format string) string {
+	sep := string(filepath.Separator)
+	base := t.Output
+	if len(base) == 0 {
+		base = GFlags.Output + sep + t.Name
+	}
+	return base + sep + format + sep
+}
+
+// 自定义导出目标名只能包含字母、数字、下划线和减号(用作输出目录名)
+// server、client 为内置目标，s、c、x 为导出模式的保留字
+func checkTargetName(name string) error {
+	switch name {
+	case "server", "client", "s", "c", "x":
+		return fmt.Errorf("target name [%s] is reserved", name)
+	}
+	if !TargetNameRe.MatchString(name) {
+		return fmt.Errorf("invalid target name [%s], only letters, digits, _ and - are allowed", name)
+	}
+	return nil
+}
+
+// 解析导出模式(s=server,c=client,x=none，多个目标逗号分隔，eg.: battle,gm)
+func parseModeTargets(mode string) []string {
+	targets := make([]string, 0, 2)
+	for _, m := range strings.Split(mode, ",") {
+		m = strings.TrimSpace(m)
+		switch m {
+		case "", "x":
+		case "s":
+			targets = append(targets, "server")
+		case "c":
+			targets = append(targets, "client")
+		default:
+			targets = append(targets, m)
+		}
+	}
+	return targets
+}
+
+// 导出模式是否合法(只检查格式，本次导出未声明的目标名不匹配任何目标)
+func isVaildModeTargets(mode string) bool {
+	if len(mode) == 0 || mode == "x" {
+		return true
+	}
+	for _, m := range strings.Split(mode, ",") {
+		m = strings.TrimSpace(m)
+		switch m {
+		case "s", "c", "server", "client":
+		default:
+			if !TargetNameRe.MatchString(m) {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,253 @@
+// 配置表文本格式(yaml)，用于版本库中审阅和比较配置表
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// 文本格式文件扩展名(eg.: D-道具@item.xlsx.yaml)
+const TextExt = ".xlsx.yaml"
+
+// 配置表的文本形式，只包含解析器读取的内容(配置数据工作表)
+// 纵向表的行为工作表中的列
+type XlsxText struct {
+	Sheet    string            `yaml:"sheet"`              // 工作表名
+	Names    textLine          `yaml:"names"`              // 字段名行
+	Types    textLine          `yaml:"types"`              // 字段类型行
+	Modes    textLine          `yaml:"modes"`              // 导出模式行
+	Descs    textLine          `yaml:"descs"`              // 字段描述行
+	Comments map[string]string `yaml:"comments,omitempty"` // 单元格 -> 批注
+	Merges   []string          `yaml:"merges,omitempty"`   // 合并单元格(eg.: E1:G1)
+	Formulas map[string]string `yaml:"formulas,omitempty"` // 单元格 -> 公式(单元格的值为公式缓存值)
+	Rows     []textLine        `yaml:"rows"`               // 配置行(含 // 注释行和空行)
+}
+
+// 一行单元格的值，输出为单行(flow 风格)便于逐行比较
+type textLine []string
+
+var textNumberRe = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?$`)
+
+func (l textLine) MarshalYAML() (any, error) {
+	node := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+	for _, v := range l {
+		// 数字不加引号，读取时按原始文本还原
+		tag := "!!str"
+		if textNumberRe.MatchString(v) {
+			tag = ternary(strings.Contains(v, "."), "!!float", "!!int")
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v})
+	}
+	return node, nil
+}
+
+// XlsxToText 读取工作簿的配置数据工作表，生成文本形式
+// 单元格的值为解析器读取到的值(格式化之后的文本)
+func XlsxToText(path string) ([]byte, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sheet, vertical := findDataSheet(f)
+	if len(sheet) == 0 {
+		return nil, errors.New("data/vdata sheet 不存在")
+	}
+	var lines [][]string
+	if vertical {
+		lines, err = f.GetCols(sheet)
+	} else {
+		lines, err = f.GetRows(sheet)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for len(lines) < HeadLineNum {
+		lines = append(lines, nil)
+	}
+
+	t := &XlsxText{
+		Sheet: sheet,
+		Names: trimLine(lines[NameLine-1]),
+		Types: trimLine(lines[TypeLine-1]),
+		Modes: trimLine(lines[ModeLine-1]),
+		Descs: trimLine(lines[DescLine-1]),
+		Rows:  make([]textLine, 0, len(lines)-HeadLineNum),
+	}
+	for _, line := range lines[HeadLineNum:] {
+		t.Rows = append(t.Rows, trimLine(line))
+	}
+
+	comments, err := f.GetComments(sheet)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range comments {
+		if t.Comments == nil {
+			t.Comments = make(map[string]string)
+		}
+		t.Comments[c.Cell] = commentText(c)
+	}
+
+	mergeCells, err := f.GetMergeCells(sheet)
+	if err != nil {
+		return nil, err
+	}
+	for _, mc := range mergeCells {
+		t.Merges = append(t.Merges, mc.GetStartAxis()+":"+mc.GetEndAxis())
+	}
+	sortAxes(t.Merges)
+
+	// 公式
+	for i, line := range lines {
+		for j := range line {
+			axis, _ := excelize.CoordinatesToCellName(ternary(vertical, i, j)+1, ternary(vertical, j, i)+1)
+			formula, err := f.GetCellFormula(sheet, axis)
+			if err != nil || len(formula) == 0 {
+				continue
+			}
+			if t.Formulas == nil {
+				t.Formulas = make(map[string]string)
+			}
+			t.Formulas[axis] = formula
+		}
+	}
+
+	var out bytes.Buffer
+	out.WriteString("# Generated by excelparser totext. Edit and run fromtext to rebuild the workbook.\n")
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(t); err != nil {
+		return nil, err
+	}
+	enc.Close()
+	return out.Bytes(), nil
+}
+
+// TextToXlsx 由文本形式重新生成工作簿(只包含配置数据工作表，不包含样式)
+func TextToXlsx(data []byte, out string) error {
+	t := &XlsxText{}
+	if err := yaml.Unmarshal(data, t); err != nil {
+		return fmt.Errorf("文本格式错误: %v", err)
+	}
+	if name := strings.TrimPrefix(t.Sheet, "v"); name != "data" && !strings.HasPrefix(name, "data@") {
+		return fmt.Errorf("工作表名[%s]错误(必须为 data 或者 vdata)", t.Sheet)
+	}
+	vertical := strings.HasPrefix(t.Sheet, "vdata")
+
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName(f.GetSheetName(0), t.Sheet); err != nil {
+		return err
+	}
+	axis := func(i, j int) string {
+		name, _ := excelize.CoordinatesToCellName(ternary(vertical, i, j)+1, ternary(vertical, j, i)+1)
+		return name
+	}
+
+	// 表头都是文本
+	for i, line := range headLines(t.Names, t.Types, t.Modes, t.Descs) {
+		for j, v := range line {
+			if len(v) > 0 {
+				f.SetCellStr(t.Sheet, axis(i, j), v)
+			}
+		}
+	}
+	for i, line := range t.Rows {
+		for j, v := range line {
+			setTextCell(f, t.Sheet, axis(HeadLineNum+i, j), v)
+		}
+	}
+
+	// 公式在值之后设置，保留单元格的值作为公式缓存值
+	for _, cell := range sortedAxes(t.Formulas) {
+		if err := f.SetCellFormula(t.Sheet, cell, t.Formulas[cell]); err != nil {
+			return fmt.Errorf("公式[%s]错误: %v", cell, err)
+		}
+	}
+	for _, ref := range t.Merges {
+		start, end, ok := strings.Cut(ref, ":")
+		if !ok {
+			return fmt.Errorf("合并单元格[%s]格式错误", ref)
+		}
+		if err := f.MergeCell(t.Sheet, start, end); err != nil {
+			return fmt.Errorf("合并单元格[%s]错误: %v", ref, err)
+		}
+	}
+	for _, cell := range sortedAxes(t.Comments) {
+		err := f.AddComment(t.Sheet, excelize.Comment{
+			Cell:      cell,
+			Author:    "excelparser",
+			Paragraph: []excelize.RichTextRun{{Text: t.Comments[cell]}},
+		})
+		if err != nil {
+			return fmt.Errorf("批注[%s]错误: %v", cell, err)
+		}
+	}
+	return f.SaveAs(out)
+}
+
+// 写入单元格，格式化后与原文本一致的数字写为数值，其他写为文本
+func setTextCell(f *excelize.File, sheet, axis, v string) {
+	switch {
+	case len(v) == 0:
+	case textNumberRe.MatchString(v) && len(v) <= 15:
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			f.SetCellInt(sheet, axis, i)
+		} else if n, err := strconv.ParseFloat(v, 64); err == nil && strconv.FormatFloat(n, 'f', -1, 64) == v {
+			f.SetCellFloat(sheet, axis, n, -1, 64)
+		} else {
+			f.SetCellStr(sheet, axis, v)
+		}
+	default:
+		f.SetCellStr(sheet, axis, v)
+	}
+}
+
+// 批注文本(与 getFieldComments 一致，拼接所有段落，保留换行)
+func commentText(c excelize.Comment) string {
+	var parts []string
+	for _, para := range c.Paragraph {
+		if len(para.Text) > 0 {
+			parts = append(parts, para.Text)
+		}
+	}
+	if len(parts) == 0 {
+		return c.Text
+	}
+	return strings.Join(parts, "")
+}
+
+// 按行、列排序单元格地址(合并单元格按起始单元格)
+func sortAxes(refs []string) {
+	coord := func(ref string) (int, int) {
+		start, _, _ := strings.Cut(ref, ":")
+		col, row, _ := excelize.CellNameToCoordinates(start)
+		return row, col
+	}
+	sort.SliceStable(refs, func(i, j int) bool {
+		ri, ci := coord(refs[i])
+		rj, cj := coord(refs[j])
+		return ri < rj || (ri == rj && ci < cj)
+	})
+}
+
+// 按单元格地址排序的键
+func sortedAxes(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sortAxes(keys)
+	return keys
+}
@@ -0,0 +1,145 @@
+package core
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var TomlBareKeyRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`) // toml 裸键正则表达式
+
+// toml 导出
+// 横向表每行配置为一个以 id 命名的表([1001])，结构体和 map 为子表，结构体数组为表数组；
+// --compact 时每行配置输出为单行内联表。toml 没有空值，json 中的 null 会被忽略
+func (t *TomlFormater) formatRows() {
+	t.line = 0
+	t.clearData()
+
+	b := &treeBuilder{Xlsx: t.Xlsx, mode: t.mode}
+	root := b.buildRows()
+	t.line = b.line
+
+	t.appendData("# Auto generated by excelparser. DO NOT EDIT!\n")
+	if t.Compact && !t.Vertical {
+		for i, k := range root.Keys {
+			t.appendData(tomlKey(k))
+			t.appendData(" = ")
+			t.appendData(tomlInline(root.Vals[i]))
+			t.appendData("\n")
+		}
+		return
+	}
+	t.formatTable(nil, root)
+}
+
+// 输出表内容，先输出键值对，再输出子表和表数组
+func (t *TomlFormater) formatTable(path []string, n *valueNode) {
+	for i, k := range n.Keys {
+		v := n.Vals[i]
+		if v.Kind == NodeNull || v.isTable() || isTomlTableArray(v) {
+			continue
+		}
+		t.appendData(tomlKey(k))
+		t.appendData(" = ")
+		t.appendData(tomlInline(v))
+		t.appendData("\n")
+	}
+
+	for i, k := range n.Keys {
+		v := n.Vals[i]
+		subpath := append(append([]string{}, path...), tomlKey(k))
+		if v.isTable() {
+			if len(t.Datas) > 1 {
+				t.appendData("\n")
+			}
+			t.appendData("[" + strings.Join(subpath, ".") + "]\n")
+			t.formatTable(subpath, v)
+		} else if isTomlTableArray(v) {
+			for _, e := range v.Vals {
+				t.appendData("\n[[" + strings.Join(subpath, ".") + "]]\n")
+				t.formatTable(subpath, e)
+			}
+		}
+	}
+}
+
+// 非空的、元素都为表的数组输出为表数组
+func isTomlTableArray(n *valueNode) bool {
+	if n.Kind != NodeList || len(n.Vals) == 0 {
+		return false
+	}
+	for _, v := range n.Vals {
+		if !v.isTable() {
+			return false
+		}
+	}
+	return true
+}
+
+// 内联值
+func tomlInline(n *valueNode) string {
+	switch n.Kind {
+	case NodeList:
+		vals := make([]string, 0, len(n.Vals))
+		for _, v := range n.Vals {
+			if v.Kind != NodeNull {
+				vals = append(vals, tomlInline(v))
+			}
+		}
+		return "[" + strings.Join(vals, ", ") + "]"
+	case NodeMap:
+		vals := make([]string, 0, len(n.Keys))
+		for i, k := range n.Keys {
+			if n.Vals[i].Kind != NodeNull {
+				vals = append(vals, tomlKey(k)+" = "+tomlInline(n.Vals[i]))
+			}
+		}
+		if len(vals) == 0 {
+			return "{}"
+		}
+		return "{ " + strings.Join(vals, ", ") + " }"
+	case NodeString:
+		return tomlString(n.Val)
+	default:
+		return n.Val
+	}
+}
+
+func tomlKey(k string) string {
+	if TomlBareKeyRe.MatchString(k) {
+		return k
+	}
+	return tomlString(k)
+}
+
+// toml 基本字符串
+func tomlString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				sb.WriteString(`\u`)
+				sb.WriteString(strings.ToUpper(strconv.FormatInt(int64(r)+0x10000, 16)[1:]))
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
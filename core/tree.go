@@ -0,0 +1,264 @@
+// 有序值树(yaml、toml 导出使用)
+
+package core
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// 值节点类型
+const (
+	NodeNull   int = iota // 空值(json null)
+	NodeInt               // 整数
+	NodeFloat             // 浮点数
+	NodeBool              // 布尔值
+	NodeString            // 字符串
+	NodeList              // 列表
+	NodeMap               // 有序键值表
+)
+
+// 值节点，键值表的键按配置表中的字段顺序排列
+type valueNode struct {
+	Kind    int
+	Val     string       // 标量值
+	Keys    []string     // 键列表(for map)
+	Vals    []*valueNode // 元素列表(for list,map)
+	IntKeys bool         // 键是否为整数(for map)
+}
+
+func (n *valueNode) isTable() bool {
+	return n.Kind == NodeMap
+}
+
+func (n *valueNode) set(key string, v *valueNode) {
+	n.Keys = append(n.Keys, key)
+	n.Vals = append(n.Vals, v)
+}
+
+// 值树构造器，与 JsonFormater.formatData 的结构一致
+type treeBuilder struct {
+	*Xlsx
+	mode string
+	line int
+	all  bool // 包含所有字段，忽略导出模式(比较配置表时使用)
+}
+
+// 横向表为 id -> 行 的键值表，纵向表为单行
+func (b *treeBuilder) buildRows() *valueNode {
+	b.line = 0
+	if b.Vertical {
+		var root *valueNode
+		for _, col := range b.Rows {
+			b.line++
+			root = b.buildField(b.RootField, col)
+		}
+		return root
+	}
+
+	root := &valueNode{Kind: NodeMap, IntKeys: true}
+	for _, row := range b.Rows {
+		b.line++
+		key := row[0]
+		if strings.HasPrefix(key, "//") || key == "" {
+			continue
+		}
+		if !b.isRowHit(row, b.mode) {
+			continue
+		}
+		root.set(key, b.buildField(b.RootField, row))
+	}
+	return root
+}
+
+func (b *treeBuilder) buildField(field *Field, row []string) *valueNode {
+	if field.isInline() {
+		return b.buildInline(field.Type, field.inlineOf(row))
+	}
+
+	switch field.Kind {
+	case TArray:
+		n := &valueNode{Kind: NodeList}
+		for _, f := range field.arrayVals(row) {
+			n.Vals = append(n.Vals, b.buildField(f, row))
+		}
+		return n
+	case TMap:
+		n := &valueNode{Kind: NodeMap, IntKeys: field.Ktype.isInteger()}
+		for i, k := range field.Keys {
+			n.set(row[k.Index], b.buildField(field.Vals[i], row))
+		}
+		return n
+	case TStruct:
+		n := &valueNode{Kind: NodeMap}
+		for _, f := range field.Vals {
+			if b.all || f.isHitMode(b.mode) {
+				n.set(f.Name, b.buildField(f, row))
+			}
+		}
+		return n
+	case TJson:
+		if len(row) > field.Index {
+			d := json.NewDecoder(bytes.NewReader([]byte(row[field.Index])))
+			d.UseNumber()
+			var result any
+			if d.Decode(&result) == nil {
+				return b.buildJson(field, field.Vtype, result)
+			}
+		}
+		return &valueNode{Kind: NodeNull}
+	default:
+		s := ""
+		if len(row) > field.Index {
+			s = row[field.Index]
+		}
+		return scalarNode(field.Type, s)
+	}
+}
+
+// 单元格内联值
+func (b *treeBuilder) buildInline(t *Type, v *inlineValue) *valueNode {
+	switch t.Kind {
+	case TArray:
+		n := &valueNode{Kind: NodeList}
+		for _, e := range v.Vals {
+			n.Vals = append(n.Vals, b.buildInline(t.Vtype, e))
+		}
+		return n
+	case TMap:
+		n := &valueNode{Kind: NodeMap, IntKeys: t.Ktype.isInteger()}
+		for i, k := range v.Keys {
+			n.set(k.Val, b.buildInline(t.Vtype, v.Vals[i]))
+		}
+		return n
+	case TStruct:
+		n := &valueNode{Kind: NodeMap}
+		for i, name := range t.Fnames {
+			n.set(name, b.buildInline(t.Ftypes[name], v.Vals[i]))
+		}
+		return n
+	default:
+		return scalarNode(t, v.Val)
+	}
+}
+
+// json 值，对象的键按名称排序，i18n 字符串按声明的类型翻译
+func (b *treeBuilder) buildJson(field *Field, t *Type, obj any) *valueNode {
+	switch val := obj.(type) {
+	case map[string]any:
+		n := &valueNode{Kind: NodeMap}
+		for _, k := range sortedKeysStringMap(val) {
+			var vt *Type
+			if t != nil {
+				switch t.Kind {
+				case TMap:
+					vt = t.Vtype
+				case TStruct:
+					vt = t.Ftypes[k]
+				}
+			}
+			n.set(k, b.buildJson(field, vt, val[k]))
+		}
+		return n
+	case []any:
+		var vt *Type
+		if t != nil && t.Kind == TArray {
+			vt = t.Vtype
+		}
+		n := &valueNode{Kind: NodeList}
+		for _, v := range val {
+			n.Vals = append(n.Vals, b.buildJson(field, vt, v))
+		}
+		return n
+	case string:
+		if t != nil && t.isI18nString() {
+			val = getI18nString(val, field, b.line+HeadLineNum)
+		}
+		return &valueNode{Kind: NodeString, Val: val}
+	case json.Number:
+		if _, err := val.Int64(); err == nil {
+			return &valueNode{Kind: NodeInt, Val: val.String()}
+		}
+		f, _ := val.Float64()
+		return &valueNode{Kind: NodeFloat, Val: formatFloatNode(f)}
+	case bool:
+		return &valueNode{Kind: NodeBool, Val: strconv.FormatBool(val)}
+	default:
+		return &valueNode{Kind: NodeNull}
+	}
+}
+
+// 基础类型值
+func scalarNode(t *Type, val string) *valueNode {
+	val = strings.TrimSpace(val)
+	switch t.Kind {
+	case TInt, TUint:
+		return &valueNode{Kind: NodeInt, Val: ternary(len(val) == 0, "0", val)}
+	case TFloat:
+		f, _ := strconv.ParseFloat(val, 64)
+		return &valueNode{Kind: NodeFloat, Val: formatFloatNode(f)}
+	case TBool:
+		return &valueNode{Kind: NodeBool, Val: ternary(val == "1" || val == "true", "true", "false")}
+	case TAny:
+		if _, err := strconv.ParseInt(val, 10, 64); err == nil {
+			return &valueNode{Kind: NodeInt, Val: val}
+		}
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			return &valueNode{Kind: NodeFloat, Val: formatFloatNode(f)}
+		}
+		return &valueNode{Kind: NodeString, Val: val}
+	default:
+		return &valueNode{Kind: NodeString, Val: val}
+	}
+}
+
+// 浮点数总是带有小数点(eg.: 1 -> 1.0)
+func formatFloatNode(f float64) string {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.ContainsAny(s, ".eEnN") {
+		s += ".0"
+	}
+	return s
+}
+
+// 转换为 json 文本(键值表保持键顺序)
+func (n *valueNode) toJson() string {
+	var sb strings.Builder
+	n.writeJson(&sb)
+	return sb.String()
+}
+
+func (n *valueNode) writeJson(sb *strings.Builder) {
+	switch n.Kind {
+	case NodeList:
+		sb.WriteByte('[')
+		for i, v := range n.Vals {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			v.writeJson(sb)
+		}
+		sb.WriteByte(']')
+	case NodeMap:
+		sb.WriteByte('{')
+		for i, k := range n.Keys {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			key, _ := json.Marshal(k)
+			sb.Write(key)
+			sb.WriteByte(':')
+			n.Vals[i].writeJson(sb)
+		}
+		sb.WriteByte('}')
+	case NodeString:
+		s, _ := json.Marshal(n.Val)
+		sb.Write(s)
+	case NodeNull:
+		sb.WriteString("null")
+	default:
+		sb.WriteString(n.Val)
+	}
+}
@@ -2,6 +2,10 @@ package core
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -10,6 +14,10 @@ func (t *Type) isVaild(inJson bool) bool {
 	if t.Kind == TNone {
 		return false
 	}
+	if len(t.Expr) > 0 && !t.isNumber() {
+		// 计算列只支持数值类型
+		return false
+	}
 
 	switch t.Kind {
 	case TAny:
@@ -20,9 +28,6 @@ func (t *Type) isVaild(inJson bool) bool {
 		if t.Vtype == nil {
 			return false
 		}
-		if !inJson && t.Cap < 0 {
-			return false
-		}
 		return t.Vtype.isVaild(inJson)
 	case TMap:
 		if !t.Ktype.isBuiltin() {
@@ -97,6 +102,74 @@ func (t *Type) defaultValue() string {
 	}
 }
 
+// 检查基础类型的值
+func (t *Type) checkValue(val string) error {
+	if len(val) == 0 {
+		return nil
+	}
+	switch t.Kind {
+	case TUint:
+		if _, err := strconv.ParseUint(val, 10, 64); err != nil {
+			return errors.New("无效的无符号整数值: " + err.Error())
+		}
+	case TInt:
+		if _, err := strconv.ParseInt(val, 10, 64); err != nil {
+			return errors.New("无效的整数值: " + err.Error())
+		}
+	case TFloat:
+		if _, err := strconv.ParseFloat(val, 64); err != nil {
+			return errors.New("无效的浮点数值: " + err.Error())
+		}
+	case TBool:
+		if !(val == "0" || val == "1" || val == "true" || val == "false") {
+			return errors.New("无效的布尔值: " + val)
+		}
+	}
+	if t.Limit != nil {
+		return t.Limit.check(t.Kind, val)
+	}
+	return nil
+}
+
+// 是否可以设置取值约束
+func (t *Type) isLimitable() bool {
+	switch t.Kind {
+	case TInt, TUint, TFloat:
+		return true
+	case TString:
+		return !t.I18n
+	}
+	return false
+}
+
+// 检查值是否满足取值约束(值的类型已检查)
+func (l *TypeLimit) check(kind int, val string) error {
+	if kind == TString {
+		if slices.Contains(l.Enum, val) {
+			return nil
+		}
+		return fmt.Errorf("值 %s 不是可选值(%s)之一", val, l.Text)
+	}
+
+	// 数值按数值比较(eg.: 1.0 与 1 相等)
+	n, err := strconv.ParseFloat(val, 64)
+	if err != nil {
+		return fmt.Errorf("值 %s 不是有效的数值", val)
+	}
+	if len(l.Enum) > 0 {
+		for _, v := range l.Enum {
+			if e, err := strconv.ParseFloat(v, 64); err == nil && e == n {
+				return nil
+			}
+		}
+		return fmt.Errorf("值 %s 不是可选值(%s)之一", val, l.Text)
+	}
+	if (l.Min != nil && n < *l.Min) || (l.Max != nil && n > *l.Max) {
+		return fmt.Errorf("值 %s 超出范围(%s)", val, l.Text)
+	}
+	return nil
+}
+
 func (t *Type) formatValue(val string) string {
 	val = strings.TrimSpace(val)
 	if len(val) == 0 {
@@ -106,7 +179,7 @@ func (t *Type) formatValue(val string) string {
 		case TString:
 			return formatString(val)
 		case TBool:
-			if val == "0" {
+			if val == "0" || val == "false" {
 				return "false"
 			} else {
 				return "true"
@@ -216,30 +289,11 @@ func (t *Type) isI18nJson() bool {
 	return false
 }
 
-func (t *Type) luaTypeName() string {
-	switch t.Kind {
-	case TInt:
-		return "integer"
-	case TUint:
-		return "integer"
-	case TFloat:
-		return "number"
-	case TBool:
-		return "boolean"
-	case TString:
-		return "string"
-	case TArray:
-		// int[]
-		return t.Vtype.luaTypeName() + "[]"
-	case TMap:
-		return "table" + "<" + t.Ktype.luaTypeName() + "," + t.Vtype.luaTypeName() + ">"
-	case TStruct:
-		return "table"
-	case TJson:
-		if t.Vtype != nil {
-			return t.Vtype.luaTypeName()
-		}
-		return "table"
+// 结构体类名(C# 类和 EmmyLua 注解共用)
+// 具名结构体使用别名，匿名结构体使用 父类名+字段名
+func structClassName(t *Type, parentClsName, fieldName string) string {
+	if len(t.Aname) > 0 {
+		return toTitle(t.Aname)
 	}
-	return "any"
+	return parentClsName + toTitle(fieldName)
 }
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypeLimit(t *testing.T) {
+	tests := []struct {
+		typ string
+		val string
+		err string
+	}{
+		{typ: "int(1,100)", val: "1"},
+		{typ: "int(1,100)", val: "100"},
+		{typ: "int(1,100)", val: "0", err: "超出范围(1,100)"},
+		{typ: "int(1,100)", val: "101", err: "超出范围"},
+		{typ: "int(1,100)", val: "", err: ""},
+		{typ: "int(0,)", val: "99999"},
+		{typ: "int(0,)", val: "-1", err: "超出范围"},
+		{typ: "float(,1)", val: "0.5"},
+		{typ: "float(,1)", val: "1.01", err: "超出范围"},
+		{typ: "uint(1|2|3)", val: "2"},
+		{typ: "uint(1|2|3)", val: "4", err: "不是可选值(1|2|3)之一"},
+		{typ: "float(0.5|1)", val: "1.0"},
+		{typ: "float(0.5|1)", val: "0.50"},
+		{typ: "int(0|1)", val: "x", err: "无效的整数值"},
+		{typ: "string(weapon|armor)", val: "armor"},
+		{typ: "string(weapon|armor)", val: "Armor", err: "不是可选值(weapon|armor)之一"},
+		{typ: "string(1|2)", val: "1.0", err: "不是可选值"},
+		{typ: "[]int(1,10)", val: "1|10"},
+		{typ: "[]int(1,10)", val: "1|11", err: "超出范围"},
+		{typ: "{id=int,quality=int(1|2|3)}", val: "{id=1,quality=4}", err: "不是可选值"},
+	}
+	for _, tt := range tests {
+		typ := parseType(tt.typ)
+		if typ.Kind == TNone {
+			t.Fatalf("%s: 类型错误", tt.typ)
+		}
+		var err error
+		if typ.isBuiltin() {
+			err = typ.checkValue(tt.val)
+		} else {
+			_, err = parseInline(typ, tt.val)
+		}
+		if len(tt.err) == 0 {
+			if err != nil {
+				t.Errorf("%s %q: unexpected error %v", tt.typ, tt.val, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("%s %q: error %v, want %q", tt.typ, tt.val, err, tt.err)
+		}
+	}
+}
+
+func TestTypeLimitCheck(t *testing.T) {
+	// 值的类型未检查时，无法解析的数值不能当作 0 处理
+	limit := &TypeLimit{Text: "0|1", Enum: []string{"0", "1"}}
+	if err := limit.check(TInt, "abc"); err == nil || !strings.Contains(err.Error(), "不是有效的数值") {
+		t.Errorf("check(abc) = %v, want 不是有效的数值", err)
+	}
+	if err := limit.check(TString, "0"); err != nil {
+		t.Errorf("check(string 0) = %v", err)
+	}
+}
+
+func TestCutTypeLimit(t *testing.T) {
+	tests := []struct {
+		typ   string
+		base  string
+		valid bool // 约束格式是否合法
+		cut   bool // 是否为约束格式
+	}{
+		{typ: "int(1,100)", base: "int", valid: true, cut: true},
+		{typ: "int(,100)", base: "int", valid: true, cut: true},
+		{typ: "string(a|b)", base: "string", valid: true, cut: true},
+		{typ: "int(100,1)", base: "int", cut: true},
+		{typ: "int(,)", base: "int", cut: true},
+		{typ: "int(a,b)", base: "int", cut: true},
+		{typ: "int(1||2)", base: "int", cut: true},
+		{typ: "float(x|1)", base: "float", cut: true},
+		{typ: "bool(0,1)"},
+		{typ: "int"},
+		{typ: "[]int(1,2)"},
+	}
+	for _, tt := range tests {
+		base, limit, ok := cutTypeLimit(tt.typ)
+		if ok != tt.cut || base != tt.base || (limit != nil) != tt.valid {
+			t.Errorf("cutTypeLimit(%s) = %q, %v, %v", tt.typ, base, limit, ok)
+		}
+	}
+	// 约束不合法或类型不支持约束时类型错误
+	for _, typ := range []string{"int(100,1)", "string(1,2)", "i18n(a|b)", "bool(0|1)"} {
+		if parseType(typ).Kind != TNone {
+			t.Errorf("parseType(%s) 应为错误类型", typ)
+		}
+	}
+}
+
+func TestFormatBool(t *testing.T) {
+	tests := []struct {
+		val  string
+		want string
+	}{
+		{val: "1", want: "true"},
+		{val: "true", want: "true"},
+		{val: "0", want: "false"},
+		{val: "false", want: "false"},
+		{val: " false ", want: "false"},
+		{val: "", want: "false"},
+	}
+	typ := parseType("bool")
+	for _, tt := range tests {
+		if got := typ.formatValue(tt.val); got != tt.want {
+			t.Errorf("formatValue(%q) = %s, want %s", tt.val, got, tt.want)
+		}
+	}
+}
@@ -131,6 +131,16 @@ func formatAxisX(x int) string {
 }
 
 func parseType(typ string) *Type {
+	if base, limit, ok := cutTypeLimit(typ); ok {
+		t := parseType(base)
+		if limit == nil || !t.isLimitable() || (t.Kind == TString && len(limit.Enum) == 0) {
+			// 约束不合法，字符串只支持枚举
+			t.Kind = TNone
+		}
+		t.Limit = limit
+		return t
+	}
+
 	t := new(Type)
 	t.Kind = TNone
 	t.Cap = -1
@@ -153,10 +163,69 @@ func parseType(typ string) *Type {
 		t.I18n = true
 	default:
 		parseCompositeType(typ, t)
+		if t.Kind == TNone {
+			// 共享类型
+			if st := findSharedType(typ); st != nil {
+				*t = *st.Type
+			}
+		}
 	}
 	return t
 }
 
+// 拆分基础类型的取值约束，不是约束格式时返回 false
+// eg.: int(1,100) -> int, [1,100]；int(,100) 只限制最大值；int(1|2|3) -> int, 枚举 1|2|3
+// 约束格式不合法时 limit 为 nil
+func cutTypeLimit(typ string) (string, *TypeLimit, bool) {
+	i := strings.IndexByte(typ, '(')
+	if i <= 0 || typ[len(typ)-1] != ')' {
+		return "", nil, false
+	}
+	base := strings.TrimSpace(typ[:i])
+	switch base {
+	case "int", "uint", "float", "string":
+	default:
+		return "", nil, false
+	}
+
+	text := strings.TrimSpace(typ[i+1 : len(typ)-1])
+	limit := &TypeLimit{Text: text}
+	if s := strings.SplitN(text, ",", 2); len(s) == 2 && !strings.Contains(text, "|") {
+		for j, v := range s {
+			v = strings.TrimSpace(v)
+			if len(v) == 0 {
+				continue
+			}
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return base, nil, true
+			}
+			if j == 0 {
+				limit.Min = &n
+			} else {
+				limit.Max = &n
+			}
+		}
+		if (limit.Min == nil && limit.Max == nil) || (limit.Min != nil && limit.Max != nil && *limit.Min > *limit.Max) {
+			return base, nil, true
+		}
+	} else {
+		for _, v := range strings.Split(text, "|") {
+			v = strings.TrimSpace(v)
+			if len(v) == 0 {
+				return base, nil, true
+			}
+			if base != "string" {
+				if _, err := strconv.ParseFloat(v, 64); err != nil {
+					return base, nil, true
+				}
+			}
+			limit.Enum = append(limit.Enum, v)
+		}
+	}
+	return base, limit, true
+}
+
 // splitStructFields 将结构体字符串按字段分割，正确处理嵌套的括号和数组
 // 输入格式：{field1=type1,field2=type2,...}
 // 返回：[]string{"field1=type1", "field2=type2", ...}
@@ -172,6 +241,7 @@ func splitStructFields(s string) []string {
 	var current strings.Builder
 	depth := 0        // 跟踪大括号嵌套深度
 	bracketDepth := 0 // 跟踪方括号嵌套深度
+	parenDepth := 0   // 跟踪圆括号嵌套深度(取值约束)
 
 	for i := range len(s) {
 		char := s[i]
@@ -188,8 +258,14 @@ func splitStructFields(s string) []string {
 		case ']':
 			bracketDepth--
 			current.WriteByte(char)
+		case '(':
+			parenDepth++
+			current.WriteByte(char)
+		case ')':
+			parenDepth--
+			current.WriteByte(char)
 		case ',':
-			if depth == 0 && bracketDepth == 0 {
+			if depth == 0 && bracketDepth == 0 && parenDepth == 0 {
 				// 只在最外层处理逗号
 				if current.Len() > 0 {
 					result = append(result, strings.TrimSpace(current.String()))
@@ -238,11 +314,18 @@ func parseCompositeType(typ string, t *Type) {
 		}
 	} else if len(typ) >= 6 && typ[:6] == "struct" {
 		// 具名结构体
+		// eg.: struct#Reward 或 struct<Reward>
 		t.Kind = TStruct
 		s := strings.SplitN(typ, "#", 2)
 		if len(s) == 2 {
 			// 结构体类型别名
 			t.Aname = s[1]
+		} else if len(typ) > 8 && typ[6] == '<' && typ[len(typ)-1] == '>' {
+			t.Aname = typ[7 : len(typ)-1]
+		}
+		if st := findSharedType(t.Aname); st != nil {
+			// 引用共享类型
+			*t = *st.Type
 		}
 	} else if len(typ) >= 4 && typ[:4] == "json" {
 		// json
@@ -256,6 +339,14 @@ func parseCompositeType(typ string, t *Type) {
 			t.Vtype = parseType(s[1])
 		}
 		t.I18n = t.isI18nJson()
+	} else if len(typ) >= 5 && typ[:5] == "calc:" {
+		// 计算列
+		// eg.: calc:int = price*3/10 由同一行的其他字段计算得出
+		s := strings.SplitN(typ[5:], "=", 2)
+		if len(s) == 2 {
+			*t = *parseType(strings.TrimSpace(s[0]))
+			t.Expr = strings.TrimSpace(s[1])
+		}
 	} else if len(typ) > 0 && typ[0] == '{' && typ[len(typ)-1] == '}' {
 		// 匿名结构体
 		// eg.: {sites=[]{name=string,url=string},age=int}
@@ -270,6 +361,9 @@ func parseCompositeType(typ string, t *Type) {
 				fname := strings.TrimSpace(kv[0])
 				ftype := strings.TrimSpace(kv[1])
 				if len(fname) > 0 && len(ftype) > 0 {
+					if _, ok := t.Ftypes[fname]; !ok {
+						t.Fnames = append(t.Fnames, fname)
+					}
 					t.Ftypes[fname] = parseType(ftype)
 				}
 			}
@@ -0,0 +1,94 @@
+// 配置表单元格数据验证(下拉列表、取值范围、输入提示)
+
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// 数据验证输入提示的长度限制(Excel 限制)
+const (
+	validationTitleLen  = 32
+	validationPromptLen = 255
+)
+
+// 根据字段类型生成数据验证，没有验证也没有输入提示时返回 nil
+// bool 和枚举生成下拉列表，取值范围生成整数/小数范围验证，其他类型只有输入提示
+// 验证失败时只警告(允许输入)，以免影响 // 注释行
+func typeValidation(t *Type, title, prompt string) *excelize.DataValidation {
+	if t == nil || len(t.Expr) > 0 {
+		// 计算列不需要填写
+		return nil
+	}
+	prompt = strings.TrimSpace(prompt)
+	dv := excelize.NewDataValidation(true)
+	switch {
+	case t.Kind == TBool:
+		if err := dv.SetDropList([]string{"true", "false"}); err != nil {
+			return nil
+		}
+		dv.SetError(excelize.DataValidationErrorStyleWarning, "取值错误", "值必须为 true 或者 false")
+	case t.Limit != nil && len(t.Limit.Enum) > 0:
+		if err := dv.SetDropList(t.Limit.Enum); err != nil {
+			// 超过下拉列表的长度限制，只检查不提示
+			return nil
+		}
+		dv.SetError(excelize.DataValidationErrorStyleWarning, "取值错误", fmt.Sprintf("值必须为(%s)之一", t.Limit.Text))
+	case t.Limit != nil:
+		vtype := excelize.DataValidationTypeWhole
+		if t.Kind == TFloat {
+			vtype = excelize.DataValidationTypeDecimal
+		}
+		var err error
+		switch {
+		case t.Limit.Min != nil && t.Limit.Max != nil:
+			err = dv.SetRange(*t.Limit.Min, *t.Limit.Max, vtype, excelize.DataValidationOperatorBetween)
+		case t.Limit.Min != nil:
+			err = dv.SetRange(*t.Limit.Min, "", vtype, excelize.DataValidationOperatorGreaterThanOrEqual)
+		default:
+			err = dv.SetRange(*t.Limit.Max, "", vtype, excelize.DataValidationOperatorLessThanOrEqual)
+		}
+		if err != nil {
+			return nil
+		}
+		dv.SetError(excelize.DataValidationErrorStyleWarning, "取值错误", fmt.Sprintf("值超出范围(%s)", t.Limit.Text))
+	case len(prompt) == 0:
+		return nil
+	}
+	if len(prompt) > 0 {
+		dv.SetInput(truncateRunes(title, validationTitleLen), truncateRunes(prompt, validationPromptLen))
+	}
+	return dv
+}
+
+// 字段的输入提示(描述和批注)
+func fieldPrompt(desc, comment string) string {
+	parts := make([]string, 0, 2)
+	for _, s := range []string{desc, comment} {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			parts = append(parts, s)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// 字段配置值所在的单元格区域(横向表为第 5 行开始的整列，纵向表为第 5 列开始的整行)
+func valueSqref(index int, vertical bool) string {
+	if vertical {
+		return fmt.Sprintf("E%d:XFD%d", index+1, index+1)
+	}
+	col := formatAxisX(index + 1)
+	return fmt.Sprintf("%s%d:%s%d", col, HeadLineNum+1, col, excelize.TotalRows)
+}
+
+// 按字符截断字符串
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n])
+}
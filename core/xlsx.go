@@ -1,10 +1,14 @@
 package core
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"math"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/xuri/excelize/v2"
@@ -26,11 +30,13 @@ func (x *Xlsx) sprintfError(format string, a ...any) {
 }
 
 func (x *Xlsx) sprintfCellError(row, col int, format string, a ...any) {
+	axis := formatAxisX(col) + strconv.Itoa(row)
 	if x.Vertical {
-		x.sprintfError("[%s%d]%s", formatAxisX(row), col, fmt.Sprintf(format, a...))
-	} else {
-		x.sprintfError("[%s%d]%s", formatAxisX(col), row, fmt.Sprintf(format, a...))
+		axis = formatAxisX(row) + strconv.Itoa(col)
 	}
+	msg := fmt.Sprintf(format, a...)
+	x.CellErrors = append(x.CellErrors, CellComment{axis, msg})
+	x.sprintfError("[%s]%s", axis, msg)
 }
 
 func (x *Xlsx) appendData(str string) {
@@ -40,22 +46,22 @@ func (x *Xlsx) appendData(str string) {
 }
 
 func (x *Xlsx) appendEOL() {
-	str := ternary(GFlags.Compact && !x.Vertical, "", "\n")
+	str := ternary(x.Compact && !x.Vertical, "", "\n")
 	x.appendData(str)
 }
 
 func (x *Xlsx) appendSpace() {
-	str := ternary(GFlags.Compact && !x.Vertical, "", " ")
+	str := ternary(x.Compact && !x.Vertical, "", " ")
 	x.appendData(str)
 }
 
 func (x *Xlsx) appendIndent(depth int) {
-	str := ternary(GFlags.Compact && !x.Vertical, "", getIndent(depth))
+	str := ternary(x.Compact && !x.Vertical, "", getIndent(depth))
 	x.appendData(str)
 }
 
 func (x *Xlsx) appendComma() {
-	str := ternary(GFlags.Compact && !x.Vertical, ",", ",\n")
+	str := ternary(x.Compact && !x.Vertical, ",", ",\n")
 	x.appendData(str)
 }
 
@@ -63,7 +69,7 @@ func (x *Xlsx) replaceComma() {
 	tailIdx := len(x.Datas) - 1
 	comma := x.Datas[tailIdx]
 	if len(comma) > 0 && comma[:1] == "," {
-		str := ternary(GFlags.Compact && !x.Vertical, "", "\n")
+		str := ternary(x.Compact && !x.Vertical, "", "\n")
 		x.Datas[tailIdx] = str
 	}
 }
@@ -90,6 +96,7 @@ func (x *Xlsx) createField(i int) *Field {
 	}
 	if i < len(x.Modes) {
 		f.Mode = strings.TrimSpace(x.Modes[i])
+		f.Targets = parseModeTargets(f.Mode)
 	}
 	if i < len(x.Descs) {
 		f.Desc = strings.TrimSpace(x.Descs[i])
@@ -106,68 +113,41 @@ func (x *Xlsx) createField(i int) *Field {
 	return f
 }
 
+// 第 i 列是否像是复合字段 f 的元素列或子字段列(字段名为空、f[] 或者 f.xxx)
+func (x *Xlsx) isElemColumn(f *Field, i int) bool {
+	if i >= len(x.Types) || len(strings.TrimSpace(x.Types[i])) == 0 {
+		return false
+	}
+	name := ""
+	if i < len(x.Names) {
+		name = strings.TrimSpace(x.Names[i])
+	}
+	return len(name) == 0 || name == f.Name+"[]" || strings.HasPrefix(name, f.Rname+".")
+}
+
+// 读取表头(纵向表为前 4 列)
 func (x *Xlsx) readSheetHead() [][]string {
-	var cur int = 0
-	var results [][]string
-	if x.Vertical {
-		// 纵向表
-		cols, err := x.Excel.Cols(x.SheetName)
-		if err != nil {
-			return nil
-		}
-		for cols.Next() {
-			cur++
-			col, err := cols.Rows()
-			if err != nil {
-				break
-			}
-			results = append(results, col)
-			if cur == HeadLineNum {
-				break
-			}
-		}
-	} else {
-		// 横向表
-		rows, err := x.Excel.Rows(x.SheetName)
-		if err != nil {
-			return nil
-		}
-		for rows.Next() {
-			cur++
-			row, err := rows.Columns()
-			if err != nil {
-				break
-			}
-			results = append(results, row)
-			if cur == HeadLineNum {
-				break
-			}
-		}
+	lines, err := x.Sheet.Lines()
+	if err != nil {
+		return nil
 	}
-	return results
+	return lines[:min(HeadLineNum, len(lines))]
 }
 
 // 获取字段批注
 func (x *Xlsx) getFieldComments() map[int]string {
 	commentMap := make(map[int]string)
-	comments, err := x.Excel.GetComments(x.SheetName)
+	comments, err := x.Sheet.Comments()
 	if err != nil {
 		return commentMap
 	}
 
 	for _, comment := range comments {
 		col, row := splitAxis(comment.Cell)
-		if (x.Vertical && col == 4) || (!x.Vertical && row == 4) {
-			// 第4列是描述列
-			var textParts []string
-			for _, para := range comment.Paragraph {
-				if len(para.Text) > 0 {
-					textParts = append(textParts, para.Text)
-				}
-			}
-			commentText := strings.Join(textParts, "")
-			commentText = strings.ReplaceAll(commentText, "\n", " ")
-			commentMap[row-1] = commentText
+		if ternary(x.Vertical, col, row) == DescLine {
+			// 描述行(纵向表为描述列)
+			commentText := strings.ReplaceAll(comment.Text, "\n", " ")
+			commentMap[ternary(x.Vertical, row, col)-1] = commentText
 		}
 	}
 
@@ -190,6 +170,10 @@ func (x *Xlsx) parseField(parent *Field, sindex int) int {
 			if !(pv.isAny() || v.Kind == pv.Kind) {
 				break
 			}
+			if parent.Cap < 0 && len(v.Name) > 0 && v.Name != parent.Name+"[]" {
+				// 变长数组的元素列不能有字段名
+				break
+			}
 
 			i++
 			v.Parent = parent
@@ -238,10 +222,16 @@ func (x *Xlsx) parseField(parent *Field, sindex int) int {
 
 			i++
 			f.Parent = parent
-			if parent.Ftypes == nil {
-				parent.Ftypes = make(map[string]*Type)
+			if !parent.isShared() {
+				// 共享类型的字段定义来自类型注册表，不能被修改
+				if parent.Ftypes == nil {
+					parent.Ftypes = make(map[string]*Type)
+				}
+				if _, ok := parent.Ftypes[f.Name]; !ok {
+					parent.Fnames = append(parent.Fnames, f.Name)
+				}
+				parent.Ftypes[f.Name] = f.Type
 			}
-			parent.Ftypes[f.Name] = f.Type
 			parent.Vals = append(parent.Vals, f)
 			if f.isRecursice() {
 				i += x.parseField(f, i)
@@ -263,12 +253,12 @@ func (x *Xlsx) parseField(parent *Field, sindex int) int {
 }
 
 func (x *Xlsx) getMergeRangeX() [][]int {
-	mergeCells, _ := x.Excel.GetMergeCells(x.SheetName)
+	mergeCells, _ := x.Sheet.MergeCells()
 	rangeX := make([][]int, 0, len(mergeCells))
 	for _, mergeCell := range mergeCells {
-		startx, starty := splitAxis(mergeCell.GetStartAxis())
-		endx, endy := splitAxis(mergeCell.GetEndAxis())
-		if starty == 1 && endy == 1 {
+		startx, starty := splitAxis(mergeCell.Start)
+		endx, endy := splitAxis(mergeCell.End)
+		if starty == NameLine && endy == NameLine {
 			rangeX = append(rangeX, []int{startx, endx})
 		}
 		if x.Vertical {
@@ -297,6 +287,23 @@ func (x *Xlsx) parseHeader() {
 	for i := 0; i < fieldNum; {
 		i += x.parseField(x.RootField, i)
 	}
+
+	x.CalcFields = x.CalcFields[:0]
+	x.collectCalcFields(x.RootField)
+
+	// 保留列(只对横向表有效)
+	x.ModeField = nil
+	x.TagField = nil
+	if !x.Vertical {
+		for _, v := range x.RootField.Vals {
+			switch v.Name {
+			case RowModeName:
+				x.ModeField = v
+			case RowTagName:
+				x.TagField = v
+			}
+		}
+	}
 }
 
 func (x *Xlsx) checkField(field *Field) {
@@ -309,6 +316,14 @@ func (x *Xlsx) checkField(field *Field) {
 	if field.Kind == TMap && len(field.Keys) != len(field.Vals) {
 		x.sprintfCellError(TypeLine, field.Index+1, "字段类型错误(map键值对不匹配)")
 	}
+	if field.isInline() {
+		if err := checkInlineType(field.Type); err != nil {
+			x.sprintfCellError(TypeLine, field.Index+1, "字段类型错误(%v)", err)
+		}
+	} else if field.isRecursice() && field.Index >= 0 && len(field.Keys) == 0 && len(field.Vals) == 0 {
+		x.sprintfCellError(TypeLine, field.Index+1, "字段类型错误(元素列或子字段列与类型不匹配)")
+	}
+	x.checkStructShape(field)
 
 	parent := field.Parent
 	if parent != nil {
@@ -361,66 +376,155 @@ func (x *Xlsx) checkFields() {
 			x.appendError("横向表 Key 字段类型必须为整数")
 		}
 	}
+	if len(keyField.Expr) > 0 {
+		x.appendError("key 字段不能是计算列")
+	}
+	if x.Vertical {
+		// 纵向表只有一行配置，不支持按行过滤
+		for _, v := range x.RootField.Vals {
+			if v.Name == RowModeName || v.Name == RowTagName {
+				x.sprintfCellError(NameLine, v.Index+1, "纵向表不支持保留列%s", v.Name)
+			}
+		}
+	}
+	for _, f := range []*Field{x.ModeField, x.TagField} {
+		if f != nil && f.Kind != TString {
+			x.sprintfCellError(TypeLine, f.Index+1, "保留列%s的类型必须为string", f.Name)
+		}
+	}
+	x.compileCalcFields()
 }
 
 func (x *Xlsx) checkRows() {
-	line := 0
+	ok := true
 	x.Rows = make([][]string, 0, 64)
+	x.BadRows = x.BadRows[:0]
+	lines, _ := x.Sheet.Lines()
 	if x.Vertical {
-		cols, _ := x.Excel.Cols(x.SheetName)
-		for cols.Next() {
-			line++
-			if line > HeadLineNum {
-				col, err := cols.Rows()
-				if err != nil {
-					break
-				}
-
-				if x.RootField.checkRow(col, line, x) {
-					x.Rows = append(x.Rows, col)
+		// 纵向表只有一行配置(第5列)
+		if len(lines) > HeadLineNum {
+			line := HeadLineNum + 1
+			col := lines[HeadLineNum]
+			if GFlags.Formula {
+				col, ok = x.evalFormulas(col, line, 0, len(x.Types))
+			}
+			if ok {
+				col = x.padRow(col)
+				if !x.addRow(col, line) {
+					x.BadRows = append(x.BadRows, "")
 				}
-				break
 			}
 		}
 	} else {
 		idMap := make(map[string]int)
-		rows, _ := x.Excel.Rows(x.SheetName)
-		for rows.Next() {
-			line++
-			if line > HeadLineNum {
-				row, err := rows.Columns()
-				if err != nil {
-					break
-				}
-				if len(row) == 0 {
-					break
+		for i := HeadLineNum; i < len(lines); i++ {
+			line := i + 1
+			row := lines[i]
+			if len(row) == 0 {
+				break
+			}
+			// 先计算 id 单元格的公式，注释行和空 id 行不计算其他公式
+			if GFlags.Formula {
+				if row, ok = x.evalFormulas(row, line, 0, 1); !ok {
+					continue
 				}
+			}
+			row = x.padRow(row)
 
-				key := row[0]
-				if strings.HasPrefix(key, "//") || key == "" {
+			key := row[0]
+			if strings.HasPrefix(key, "//") || key == "" {
+				continue
+			}
+			if GFlags.Formula {
+				if row, ok = x.evalFormulas(row, line, 1, len(x.Types)); !ok {
 					continue
 				}
+			}
 
-				num, ok := idMap[key]
-				if ok {
-					x.sprintfCellError(line, 1, "Id [%s] 重复 %d 次", key, num-1)
-				} else {
-					idMap[key] += 1
-				}
+			num, ok := idMap[key]
+			if ok {
+				x.sprintfCellError(line, 1, "Id [%s] 重复 %d 次", key, num-1)
+			} else {
+				idMap[key] += 1
+			}
+
+			if !x.addRow(row, line) {
+				x.BadRows = append(x.BadRows, key)
+			}
+		}
+	}
+}
+
+// 检查配置行并计算计算列，通过检查的行加入 Rows
+func (x *Xlsx) addRow(row []string, line int) bool {
+	if !x.RootField.checkRow(row, line, x) {
+		return false
+	}
+	row, ok := x.evalCalcFields(row, line)
+	if ok {
+		x.Rows = append(x.Rows, row)
+	}
+	return ok
+}
+
+// 补齐配置行的列数(excelize 不返回行尾的空单元格)
+func (x *Xlsx) padRow(row []string) []string {
+	if n := len(x.Types); len(row) < n {
+		row = append(row, make([]string, n-len(row))...)
+	}
+	return row
+}
 
-				if x.RootField.checkRow(row, line, x) {
-					x.Rows = append(x.Rows, row)
+// 配置行是否需要导出到目标(__mode 列)，以及是否带有指定的标签(__tag 列)
+// 未填写 __mode 的行导出到所有目标，未填写 __tag 的行总是导出，
+// 带有标签的行只有在 --tags 包含其中某个标签时才导出；
+// --tags 中以 ! 开头的为排除的标签，带有排除标签的行不导出，
+// --tags 只有排除的标签时，其他带有标签的行都导出
+func (x *Xlsx) isRowHit(row []string, mode string) bool {
+	if f := x.ModeField; f != nil && f.Index < len(row) {
+		m := strings.TrimSpace(row[f.Index])
+		if len(m) > 0 && !slices.Contains(parseModeTargets(m), mode) {
+			return false
+		}
+	}
+	if f := x.TagField; f != nil && f.Index < len(row) {
+		tags := strings.TrimSpace(row[f.Index])
+		if len(tags) > 0 {
+			include, exclude := splitTags(GFlags.Tags)
+			hit := len(include) == 0 && len(exclude) > 0
+			for _, tag := range strings.Split(tags, ",") {
+				tag = strings.TrimSpace(tag)
+				if slices.Contains(exclude, tag) {
+					return false
+				}
+				if slices.Contains(include, tag) {
+					hit = true
 				}
 			}
+			return hit
 		}
 	}
+	return true
+}
+
+// 拆分 --tags 中包含和排除(!tag)的标签
+func splitTags(tags []string) (include, exclude []string) {
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if name, ok := strings.CutPrefix(tag, "!"); ok {
+			exclude = append(exclude, strings.TrimSpace(name))
+		} else if len(tag) > 0 {
+			include = append(include, tag)
+		}
+	}
+	return
 }
 
 // 是否文件已修改
 func (x *Xlsx) isModified(mode, format string) *ExportInfo {
 	for _, v := range x.Exports {
 		if v.Mode == mode && v.Format == format {
-			if (v.LastTime != x.LastModified) || GFlags.Force {
+			if v.LastTime != x.lastModified() || v.Settings != x.settingsHash(mode) || GFlags.Force {
 				// 文件已修改
 				return &v
 			} else {
@@ -429,19 +533,16 @@ func (x *Xlsx) isModified(mode, format string) *ExportInfo {
 		}
 	}
 
-	return &ExportInfo{mode, format, 0}
+	return &ExportInfo{Mode: mode, Format: format}
 }
 
 func (x *Xlsx) GetNeedParse() []ExportInfo {
 	needParse := make([]ExportInfo, 0, len(GFlags.Server)+len(GFlags.Client))
-	for _, format := range GFlags.Server {
-		if v := x.isModified("server", format); v != nil {
-			needParse = append(needParse, *v)
-		}
-	}
-	for _, format := range GFlags.Client {
-		if v := x.isModified("client", format); v != nil {
-			needParse = append(needParse, *v)
+	for _, t := range ExportTargets() {
+		for _, format := range t.Formats {
+			if v := x.isModified(t.Name, format); v != nil {
+				needParse = append(needParse, *v)
+			}
 		}
 	}
 	return needParse
@@ -457,41 +558,62 @@ func (x *Xlsx) updateExportInfo(mode, format string) {
 		}
 	}
 	if e != nil {
-		e.LastTime = x.LastModified
+		e.LastTime, e.Settings = x.lastModified(), x.settingsHash(mode)
 	} else {
-		x.Exports = append(x.Exports, ExportInfo{mode, format, x.LastModified})
+		x.Exports = append(x.Exports, ExportInfo{mode, format, x.lastModified(), x.settingsHash(mode)})
 	}
 }
 
-// 解析excel表头并静态检查表数据
-func (x *Xlsx) parseExcel() bool {
-	var vertical bool
-	f := x.Excel
+// 最后修改时间(共享类型定义修改后，所有配置表都需要重新导出)
+func (x *Xlsx) lastModified() uint64 {
+	return max(x.LastModified, SharedTypesTime)
+}
+
+// 影响导出结果的设置摘要(命令行参数、项目配置及单表设置)，与文件修改时间一起记录在导出缓存中
+// 设置变化(包括修改 excelparser.yaml)后重新导出，不需要 --force
+func (x *Xlsx) settingsHash(mode string) string {
+	h := sha256.New()
+	fmt.Fprintln(h, NameLine, TypeLine, ModeLine, DescLine)
+	fmt.Fprintln(h, GFlags.Compact, GFlags.Pretty, GFlags.Formula, GFlags.LuaOpt, GFlags.LuaCols, GFlags.Tags)
+	fmt.Fprintln(h, GFlags.I18nPath, GFlags.I18nLang, GFlags.Compress, GFlags.Bundle)
+	if len(packKey) > 0 {
+		// 不直接写入密钥
+		sum := sha256.Sum256(packKey)
+		h.Write(sum[:])
+	}
+	if t := FindTarget(mode); t != nil {
+		// 导出目录、导出格式变化后需要重新导出
+		fmt.Fprintln(h, t.outDir(""), t.Formats)
+	}
+	if t := x.tableConfig(); t != nil {
+		fmt.Fprintln(h, t.Compact != nil && *t.Compact, t.Compact == nil, t.Indent != nil && *t.Indent, t.Indent == nil, t.Key)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// 查找配置数据所在的工作表(data 或者纵向表 vdata)，不存在时返回空
+func findDataSheet(f *excelize.File) (string, bool) {
+	return findDataSheetName(f.GetSheetList())
+}
 
-	sheetIdx := -1
-	sheetNames := f.GetSheetList()
+func findDataSheetName(sheetNames []string) (string, bool) {
 	for _, name := range sheetNames {
 		if name == "data" || strings.HasPrefix(name, "data@") {
-			sheetIdx, _ = f.GetSheetIndex(name)
-			break
+			return name, false
 		}
 	}
-	if sheetIdx == -1 {
-		vertical = true
-		for _, name := range sheetNames {
-			if name == "vdata" || strings.HasPrefix(name, "vdata@") {
-				sheetIdx, _ = f.GetSheetIndex(name)
-				break
-			}
+	for _, name := range sheetNames {
+		if name == "vdata" || strings.HasPrefix(name, "vdata@") {
+			return name, true
 		}
 	}
-	if sheetIdx == -1 {
-		x.appendError("data/vdata sheet 不存在")
-		return false
-	}
+	return "", false
+}
 
-	x.Vertical = vertical
-	x.SheetName = f.GetSheetName(sheetIdx)
+// 解析excel表头并静态检查表数据
+func (x *Xlsx) parseExcel() bool {
+	x.Vertical = x.Sheet.Vertical()
+	x.SheetName = x.Sheet.Name()
 	heads := x.readSheetHead()
 	if len(heads) < HeadLineNum {
 		x.appendError("配置表头格式错误(不足4行)")
@@ -502,6 +624,7 @@ func (x *Xlsx) parseExcel() bool {
 	x.Types = heads[TypeLine-1] // 字段类型行
 	x.Modes = heads[ModeLine-1] // 导出模式行
 	x.Descs = heads[DescLine-1] // 字段描述行
+	x.applyTableConfig()
 	x.Comments = x.getFieldComments()
 	x.parseHeader()
 	x.checkFields()
@@ -521,6 +644,11 @@ func (x *Xlsx) exportModeExcel(mode, format string) bool {
 	formater := NewFormater(x, format, mode)
 	formater.formatRows()
 
+	// 压缩、加密
+	if len(x.Errors) == 0 {
+		x.packOutput(format)
+	}
+
 	// write
 	if len(x.Errors) == 0 {
 		x.updateExportInfo(mode, format)
@@ -530,14 +658,14 @@ func (x *Xlsx) exportModeExcel(mode, format string) bool {
 }
 
 func (x *Xlsx) exportExcel(needParse []ExportInfo) {
-	f, err := excelize.OpenFile(x.PathName)
+	sheet, err := OpenSheet(x.PathName)
 	if err == nil {
 		defer func() {
-			x.Excel = nil
-			f.Close()
+			x.Sheet = nil
+			sheet.Close()
 		}()
 
-		x.Excel = f
+		x.Sheet = sheet
 		ok := x.parseExcel()
 		if ok && len(x.Errors) == 0 && len(x.Rows) > 0 {
 			x.Datas = make([]string, 0)
@@ -545,30 +673,41 @@ func (x *Xlsx) exportExcel(needParse []ExportInfo) {
 				x.exportModeExcel(v.Mode, v.Format)
 			}
 		}
+		if GFlags.ErrorReport {
+			x.writeErrorReport()
+		}
 	} else {
-		x.appendError("xlsx文件打开失败")
+		x.appendError(err.Error())
 	}
 }
 
-func (x *Xlsx) writeToFile(mode, format string) {
-	ext := ""
+// 导出数据文件扩展名
+func outputExt(format string) string {
 	switch format {
-	case "lua":
-		ext = "lua"
-	case "json":
-		ext = "json"
+	case "lua", "json", "yaml", "toml":
+		return format
 	case "csharp":
-		ext = "bytes"
+		return "bytes"
+	}
+	return ""
+}
+
+func (x *Xlsx) writeToFile(mode, format string) {
+	ext := outputExt(format)
+	target := FindTarget(mode)
+	if target == nil {
+		x.sprintfError("导出目标[%s]不存在", mode)
+		return
 	}
-	sep := string(filepath.Separator)
-	// linux: out/server/json
-	// windows: out\server\json
-	outdir := GFlags.Output + sep + mode + sep + format + sep
+	outdir := target.outDir(format)
 	outFileName := fmt.Sprintf("%s%s.%s", outdir, x.OutName, ext)
 	err := os.MkdirAll(filepath.Dir(outFileName), 0o755)
 	if err == nil || os.IsExist(err) {
 		if format == "csharp" {
 			x.writeCSharpFiles(outdir, outFileName)
+		} else if format == "sqlite" {
+			// 所有配置表解析完成后统一写入数据库
+			addSqliteTable(outdir, x.SqlTable)
 		} else {
 			outFile, operr := os.OpenFile(outFileName, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0o666)
 			if operr != nil {
@@ -629,23 +768,33 @@ func (x *Xlsx) collectResult(costFormat, infoFormat, splitline string) []string
 			}
 		}
 	}
+	if len(x.ErrorReport) > 0 {
+		results = append(results, fmt.Sprintf(infoFormat, "", "错误报告: "+x.ErrorReport))
+	}
 	return results
 }
 
 func (x *Xlsx) formatLuaComment(mode string) string {
 	clsName := "T" + toTitle(x.OutName)
+	a := newLuaAnnotator(mode)
 	comments := make([]string, 0, len(x.RootField.Vals)+3)
 	comments = append(comments, "---"+x.FileName)
 	comments = append(comments, "---@class "+clsName)
 	for _, v := range x.RootField.Vals {
 		if v.isHitMode(mode) {
-			field := "---@field " + v.Name + " " + v.luaTypeName() + " " + v.Desc
+			field := "---@field " + v.Name + " " + a.fieldTypeName(v, clsName, v.Name) + " " + v.Desc
 			if len(v.Comment) > 0 {
 				field += "  (" + v.Comment + ")"
 			}
 			comments = append(comments, field)
 		}
 	}
+	for _, cls := range a.classes {
+		comments = append(comments, "", cls)
+	}
+	if t := FindTarget(mode); t != nil && len(a.aliases) > 0 {
+		x.addLuaAliasClasses(t.outDir("lua"), a.aliases)
+	}
 	if x.Vertical {
 		comments = append(comments, "\n---@type "+clsName)
 	} else {
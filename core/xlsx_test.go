@@ -0,0 +1,71 @@
+package core
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplitTags(t *testing.T) {
+	tests := []struct {
+		tags    []string
+		include []string
+		exclude []string
+	}{
+		{tags: nil},
+		{tags: []string{"dev"}, include: []string{"dev"}},
+		{tags: []string{" dev ", "", "!debug"}, include: []string{"dev"}, exclude: []string{"debug"}},
+		{tags: []string{"! debug", "!gm"}, exclude: []string{"debug", "gm"}},
+	}
+	for _, tt := range tests {
+		include, exclude := splitTags(tt.tags)
+		if !slices.Equal(include, tt.include) || !slices.Equal(exclude, tt.exclude) {
+			t.Errorf("splitTags(%q) = %q, %q, want %q, %q", tt.tags, include, exclude, tt.include, tt.exclude)
+		}
+	}
+}
+
+func TestIsRowHit(t *testing.T) {
+	tags := GFlags.Tags
+	t.Cleanup(func() { GFlags.Tags = tags })
+
+	x := newTestXlsx(t, []string{"id", RowModeName, RowTagName}, []string{"int", "string", "string"})
+	if x.ModeField == nil || x.TagField == nil {
+		t.Fatal("保留列未识别")
+	}
+
+	// 行: id, __mode, __tag
+	rows := [][]string{
+		{"1", "", ""},
+		{"2", "", "dev"},
+		{"3", "", "debug"},
+		{"4", "", "dev,debug"},
+		{"5", "s", ""},
+		{"6", "c,server", "dev"},
+		{"7", "x", ""},
+	}
+	tests := []struct {
+		tags []string
+		mode string
+		want string // 命中的行 id
+	}{
+		{mode: "server", want: "1,5"},
+		{mode: "client", want: "1"},
+		{tags: []string{"dev"}, mode: "server", want: "1,2,4,5,6"},
+		{tags: []string{"!debug"}, mode: "server", want: "1,2,5,6"},
+		{tags: []string{"dev", "!debug"}, mode: "server", want: "1,2,5,6"},
+		{tags: []string{"debug"}, mode: "client", want: "1,3,4"},
+	}
+	for _, tt := range tests {
+		GFlags.Tags = tt.tags
+		var hits []string
+		for _, row := range rows {
+			if x.isRowHit(row, tt.mode) {
+				hits = append(hits, row[0])
+			}
+		}
+		if got := strings.Join(hits, ","); got != tt.want {
+			t.Errorf("tags %q mode %s: rows %s, want %s", tt.tags, tt.mode, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"bytes"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yaml 导出，结构与 json 一致，--compact 时每行配置输出为单行(flow 风格)
+func (y *YamlFormater) formatRows() {
+	y.line = 0
+	y.clearData()
+
+	b := &treeBuilder{Xlsx: y.Xlsx, mode: y.mode}
+	root := b.buildRows()
+	y.line = b.line
+
+	node := y.toYamlNode(root, 0)
+	var out bytes.Buffer
+	out.WriteString("# Auto generated by excelparser. DO NOT EDIT!\n")
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		y.sprintfError("yaml 导出失败: %v", err)
+		return
+	}
+	enc.Close()
+	y.appendData(out.String())
+}
+
+func (y *YamlFormater) toYamlNode(n *valueNode, depth int) *yaml.Node {
+	switch n.Kind {
+	case NodeList:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, v := range n.Vals {
+			node.Content = append(node.Content, y.toYamlNode(v, depth+1))
+		}
+		y.setStyle(node, depth)
+		return node
+	case NodeMap:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for i, k := range n.Keys {
+			key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k}
+			if n.IntKeys {
+				key.Tag = "!!int"
+			}
+			node.Content = append(node.Content, key, y.toYamlNode(n.Vals[i], depth+1))
+		}
+		y.setStyle(node, depth)
+		return node
+	case NodeInt:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: n.Val}
+	case NodeFloat:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: n.Val}
+	case NodeBool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: n.Val}
+	case NodeString:
+		node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: n.Val}
+		if strings.Contains(n.Val, "\n") && !y.Compact {
+			node.Style = yaml.LiteralStyle
+		}
+		return node
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+}
+
+// 紧凑模式下，横向表的每行配置(纵向表的每个字段)使用 flow 风格
+func (y *YamlFormater) setStyle(node *yaml.Node, depth int) {
+	if y.Compact && depth == 1 {
+		node.Style = yaml.FlowStyle
+	}
+}
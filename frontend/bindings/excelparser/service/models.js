@@ -57,6 +57,36 @@ export class AppConfig {
              */
             this["client_fmts"] = [];
         }
+        if (!("compact" in $$source)) {
+            /**
+             * 项目配置(excelparser.yaml)中的导出选项，只用于界面初始值
+             * @member
+             * @type {boolean}
+             */
+            this["compact"] = false;
+        }
+        if (!("indent" in $$source)) {
+            /**
+             * @member
+             * @type {boolean}
+             */
+            this["indent"] = false;
+        }
+        if (!("error_report" in $$source)) {
+            /**
+             * @member
+             * @type {boolean}
+             */
+            this["error_report"] = false;
+        }
+        if (/** @type {any} */(false)) {
+            /**
+             * 使用的项目配置文件(不保存)
+             * @member
+             * @type {string | undefined}
+             */
+            this["project_config"] = undefined;
+        }
 
         Object.assign(this, $$source);
     }
@@ -134,6 +164,14 @@ export class ExportProgressEvent {
              */
             this["messages"] = [];
         }
+        if (!("report" in $$source)) {
+            /**
+             * 错误报告工作簿路径(开启错误报告且导出失败时)
+             * @member
+             * @type {string}
+             */
+            this["report"] = "";
+        }
         if (!("seq" in $$source)) {
             /**
              * 事件序列号，用于前端排序
@@ -22,6 +22,7 @@ export function useExcelParser() {
     const forceExport = ref(false);
     const compactOutput = ref(false);
     const prettyOutput = ref(false);
+    const errorReport = ref(false);
 
     // ── 文件列表 ──
     const fileList = ref([]);
@@ -111,6 +112,7 @@ export function useExcelParser() {
                 exportStatus: 0,
                 exportResult: "-",
                 exportErrors: [],
+                errorReport: "",
             }));
         } catch (err) {
             message.error(`加载配置表失败: ${String(err)}`);
@@ -137,11 +139,18 @@ export function useExcelParser() {
                 translateLang.value = config.i18n_lang || "";
                 serverFormats.value = config.server_fmts || [];
                 clientFormats.value = config.client_fmts || [];
+                compactOutput.value = !!config.compact;
+                prettyOutput.value = !config.compact && !!config.indent;
+                errorReport.value = !!config.error_report;
+                if (config.project_config) {
+                    message.info(`使用项目配置 ${config.project_config}，其中的路径和导出格式优先于界面保存的设置`);
+                }
                 await loadLangOptions();
                 await loadXlsxList(configPath.value);
             }
         } catch (err) {
             console.error("加载配置失败:", err);
+            statusText.value = "加载配置失败";
         } finally {
             isLoadingConfig.value = false;
         }
@@ -182,6 +191,13 @@ export function useExcelParser() {
                 }
                 await FileService.OpenFile(contextMenuRow.value.filepath);
                 message.success("已打开文件");
+            } else if (key === "open-report") {
+                if (!contextMenuRow.value?.errorReport) {
+                    message.warning("没有错误报告（需勾选错误报告并导出失败）");
+                    return;
+                }
+                await FileService.OpenFile(contextMenuRow.value.errorReport);
+                message.success("已打开错误报告");
             }
         } catch (err) {
             message.error(`操作失败: ${String(err)}`);
@@ -262,6 +278,7 @@ export function useExcelParser() {
             row.exportStatus = 0;
             row.exportResult = "-";
             row.exportErrors = [];
+            row.errorReport = "";
         });
 
         try {
@@ -291,6 +308,9 @@ export function useExcelParser() {
     watch(forceExport, (val) => {
         FileService.SetExportFlag(3, val).catch((err) => console.error("SetExportFlag force:", err));
     });
+    watch(errorReport, (val) => {
+        FileService.SetExportFlag(4, val).catch((err) => console.error("SetExportFlag error report:", err));
+    });
     watch(
         serverFormats,
         (val) => {
@@ -328,6 +348,9 @@ export function useExcelParser() {
                 if (payload.messages && payload.messages.length > 0) {
                     row.exportErrors = payload.messages;
                 }
+                if (payload.report) {
+                    row.errorReport = payload.report;
+                }
             }
             console.log(`row updated for ${path}:`, row.exportStatus, row.exportResult);
         }
@@ -380,6 +403,7 @@ export function useExcelParser() {
         forceExport,
         compactOutput,
         prettyOutput,
+        errorReport,
         fileList,
         isExporting,
         statusText,
@@ -3,19 +3,442 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"excelparser/core"
 	"flag"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 func main() {
 	flag.Parse()
-	if core.Flaghelp || flag.NFlag() <= 0 {
+
+	// 项目配置(excelparser.yaml)，命令行参数优先
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	config, err := core.ApplyProjectConfig(core.ConfigFile, set)
+	if err != nil {
+		if flag.NArg() == 0 || needProjectConfig(flag.Arg(0)) {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		// 其他子命令不依赖配置文件(diff、textconv、merge 由 git 调用)，配置错误时只给出警告
+		fmt.Fprintf(os.Stderr, "警告: 项目配置未生效: %v\n", err)
+	}
+	if core.Flaghelp || (flag.NFlag() <= 0 && flag.NArg() <= 0 && len(config) == 0) {
 		flag.Usage()
 		return
 	}
 
-	core.Run(nil)
+	if flag.NArg() > 0 {
+		if err := runCommand(flag.Arg(0), flag.Args()[1:]); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := core.Run(nil); err != nil {
+		fmt.Println(err)
+		return
+	}
 	fmt.Printf("Total Cost: %d ms\n", core.ExportCost)
 	// fmt.Printf("running goroutines: %d\n", p.Running())
 }
+
+// 子命令是否必须使用项目配置(生成工作簿需要表头行布局)
+func needProjectConfig(name string) bool {
+	return name == "new" || name == "import"
+}
+
+// 子命令
+func runCommand(name string, args []string) error {
+	switch name {
+	case "manifest-diff":
+		return manifestDiff(args)
+	case "diff":
+		return diffXlsx(args)
+	case "textconv":
+		return textconv(args)
+	case "merge":
+		return mergeXlsx(args)
+	case "totext":
+		return convertText(args, true)
+	case "fromtext":
+		return convertText(args, false)
+	case "new":
+		return newXlsx(args)
+	case "annotate":
+		return annotateXlsx(args)
+	case "import":
+		return importXlsx(args)
+	default:
+		return fmt.Errorf("未知的命令[%s]", name)
+	}
+}
+
+// 比较两个清单，输出需要更新的文件列表(A=新增, M=修改, D=删除)
+func manifestDiff(args []string) error {
+	if len(args) != 2 {
+		return errors.New("用法: excelparser manifest-diff OLD NEW")
+	}
+	old, err := core.ReadManifest(args[0])
+	if err != nil {
+		return err
+	}
+	cur, err := core.ReadManifest(args[1])
+	if err != nil {
+		return err
+	}
+	for _, c := range core.DiffManifest(old, cur) {
+		fmt.Printf("%s\t%s\n", c.Status, c.Name)
+	}
+	return nil
+}
+
+// 比较配置表的两个版本
+// eg.: excelparser diff OLD NEW
+//
+//	excelparser diff --rev=HEAD~1 FILE
+func diffXlsx(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	asJson := fs.Bool("json", false, "Output json.")
+	rev := fs.String("rev", "", "Compare FILE at the git revision with the working tree.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var oldFile, newFile string
+	switch {
+	case len(*rev) > 0 && fs.NArg() == 1:
+		newFile = fs.Arg(0)
+		tmpFile, cleanup, err := core.GitShowFile(newFile, *rev)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		oldFile = tmpFile
+	case len(*rev) == 0 && fs.NArg() == 2:
+		oldFile, newFile = fs.Arg(0), fs.Arg(1)
+	default:
+		return errors.New("用法: excelparser diff [--json] OLD NEW 或 excelparser diff [--json] --rev=REV FILE")
+	}
+
+	if err := loadSharedTypes(); err != nil {
+		return err
+	}
+	old, err := core.LoadXlsx(oldFile)
+	if err != nil {
+		return err
+	}
+	cur, err := core.LoadXlsx(newFile)
+	if err != nil {
+		return err
+	}
+	d := core.DiffXlsx(old, cur)
+	if len(*rev) > 0 {
+		d.Old = *rev + ":" + newFile
+	}
+	if *asJson {
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(d.Text())
+	}
+	return nil
+}
+
+// 输出配置表的文本形式(git textconv)
+func textconv(args []string) error {
+	if len(args) != 1 {
+		return errors.New("用法: excelparser textconv FILE")
+	}
+	if err := loadSharedTypes(); err != nil {
+		return err
+	}
+	x, err := core.LoadXlsx(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Print(core.TextConv(x))
+	return nil
+}
+
+// 三方合并配置表，结果写入 OURS(git 合并驱动: excelparser merge %O %A %B %P)
+// 有冲突时返回错误(退出码非 0)
+func mergeXlsx(args []string) error {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	output := fs.String("o", "", "Write the merged workbook to this file instead of OURS.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 3 || fs.NArg() > 4 {
+		return errors.New("用法: excelparser merge [-o FILE] BASE OURS THEIRS [PATH]")
+	}
+	base, ours, theirs := fs.Arg(0), fs.Arg(1), fs.Arg(2)
+	name := ours
+	if fs.NArg() == 4 {
+		name = fs.Arg(3)
+	}
+	out := ours
+	if len(*output) > 0 {
+		out = *output
+	}
+
+	if err := loadSharedTypes(); err != nil {
+		return err
+	}
+	result, err := core.MergeXlsx(base, ours, theirs, out)
+	if err != nil {
+		return fmt.Errorf("%s 合并失败: %v", name, err)
+	}
+	fmt.Printf("%s: 合并 %d 个单元格，新增 %d 行，删除 %d 行\n", name, result.Updated, result.Added, result.Deleted)
+	if len(result.Conflicts) > 0 {
+		for _, c := range result.Conflicts {
+			fmt.Println("  冲突" + c.String())
+		}
+		return fmt.Errorf("%s 有 %d 处冲突，已在单元格批注中标出", name, len(result.Conflicts))
+	}
+	return nil
+}
+
+// 工作簿和文本形式互相转换，参数可以是文件或者目录(转换目录下所有文件)
+// eg.: excelparser totext [-o DIR] PATH...
+//
+//	excelparser fromtext [-o DIR] PATH...
+func convertText(args []string, toText bool) error {
+	name := "totext"
+	if !toText {
+		name = "fromtext"
+	}
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	outDir := fs.String("o", "", "Output directory (default: next to the source file).")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("用法: excelparser %s [-o DIR] PATH...", name)
+	}
+
+	srcExt, dstExt := ".xlsx", core.TextExt
+	if !toText {
+		srcExt, dstExt = dstExt, srcExt
+	}
+	convert := func(src, rel string) error {
+		dst := strings.TrimSuffix(src, srcExt) + dstExt
+		if len(*outDir) > 0 {
+			dst = filepath.Join(*outDir, strings.TrimSuffix(rel, srcExt)+dstExt)
+			if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+				return err
+			}
+		}
+		var err error
+		if toText {
+			var data []byte
+			if data, err = core.XlsxToText(src); err == nil {
+				err = os.WriteFile(dst, data, 0o666)
+			}
+		} else {
+			var data []byte
+			if data, err = os.ReadFile(src); err == nil {
+				err = core.TextToXlsx(data, dst)
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%s 转换失败: %v", src, err)
+		}
+		fmt.Printf("%s -> %s\n", src, dst)
+		return nil
+	}
+
+	var errs []error
+	for _, path := range fs.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !info.IsDir() {
+			if err := convert(path, filepath.Base(path)); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		filepath.Walk(path, func(file string, f os.FileInfo, err error) error {
+			if err != nil || f.IsDir() || strings.HasPrefix(f.Name(), "~$") || !strings.HasSuffix(f.Name(), srcExt) {
+				return err
+			}
+			rel, _ := filepath.Rel(path, file)
+			if err := convert(file, rel); err != nil {
+				errs = append(errs, err)
+			}
+			return nil
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// 由表结构定义生成配置表工作簿
+// eg.: excelparser new [-o FILE] D-道具@item.yaml
+//
+//	excelparser new -type Item -o D-道具@item.xlsx item.go
+func newXlsx(args []string) error {
+	fs := flag.NewFlagSet("new", flag.ContinueOnError)
+	output := fs.String("o", "", "Output workbook (default: SCHEMA with .xlsx extension).")
+	typeName := fs.String("type", "", "Type name in a go/c# file (default: the first type).")
+	vertical := fs.Bool("vertical", false, "Generate a vertical table (vdata sheet).")
+	force := fs.Bool("f", false, "Overwrite the output workbook if it exists.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("用法: excelparser new [-o FILE] [-type NAME] [-vertical] [-f] SCHEMA")
+	}
+	src := fs.Arg(0)
+	out := *output
+	if len(out) == 0 {
+		out = strings.TrimSuffix(src, filepath.Ext(src)) + ".xlsx"
+	}
+	if _, err := os.Stat(out); err == nil && !*force {
+		return fmt.Errorf("%s 已存在(使用 -f 覆盖)", out)
+	}
+
+	if err := loadSharedTypes(); err != nil {
+		return err
+	}
+	schema, err := core.ReadSchema(src, *typeName)
+	if err != nil {
+		return fmt.Errorf("%s 读取失败: %v", src, err)
+	}
+	if *vertical {
+		schema.Vertical = true
+	}
+	if err := core.NewXlsxFromSchema(schema, out); err != nil {
+		return fmt.Errorf("%s 生成失败: %v", out, err)
+	}
+	fmt.Printf("%s -> %s\n", src, out)
+	return nil
+}
+
+// 在配置表中写入数据验证、输入提示和错误标记，参数可以是文件或者目录
+// eg.: excelparser annotate [-clear] PATH...
+func annotateXlsx(args []string) error {
+	fs := flag.NewFlagSet("annotate", flag.ContinueOnError)
+	clear := fs.Bool("clear", false, "Remove annotations written by annotate.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("用法: excelparser annotate [-clear] PATH...")
+	}
+	if err := loadSharedTypes(); err != nil {
+		return err
+	}
+
+	var errs []error
+	annotate := func(file string) {
+		result, err := core.AnnotateXlsx(file, *clear)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s 标注失败: %v", file, err))
+			return
+		}
+		if *clear {
+			fmt.Printf("%s: 已清除标注\n", file)
+			return
+		}
+		fmt.Printf("%s: %d 个数据验证，%d 个错误单元格\n", file, result.Validations, result.ErrorCells)
+		for _, e := range result.Errors {
+			fmt.Println("  " + e)
+		}
+	}
+	for _, path := range fs.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !info.IsDir() {
+			annotate(path)
+			continue
+		}
+		filepath.Walk(path, func(file string, f os.FileInfo, err error) error {
+			if err != nil || f.IsDir() || strings.HasPrefix(f.Name(), "~$") || !strings.HasSuffix(f.Name(), ".xlsx") {
+				return err
+			}
+			annotate(file)
+			return nil
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// 由 json/lua 导出数据生成或更新配置表
+// eg.: excelparser import out/server/json/item.json D-道具@item.xlsx
+//
+//	excelparser import -schema item.yaml -o D-道具@item.xlsx item.json
+func importXlsx(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	output := fs.String("o", "", "Write the workbook to this file instead of WORKBOOK.")
+	schemaPath := fs.String("schema", "", "Table schema (yaml/json/go/cs) used to create the workbook when it does not exist.")
+	typeName := fs.String("type", "", "Type name in a go/c# schema file (default: the first type).")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 1 || fs.NArg() > 2 || (fs.NArg() == 1 && len(*output) == 0) {
+		return errors.New("用法: excelparser import [-o FILE] [-schema SCHEMA [-type NAME]] DATA [WORKBOOK]")
+	}
+	src := fs.Arg(0)
+	template := fs.Arg(1)
+	out := *output
+	if len(out) == 0 {
+		out = template
+	}
+
+	if err := loadSharedTypes(); err != nil {
+		return err
+	}
+	created := false
+	if len(template) == 0 {
+		template = out
+	}
+	if _, err := os.Stat(template); err != nil {
+		if !os.IsNotExist(err) || len(*schemaPath) == 0 {
+			return fmt.Errorf("%s 不存在(使用 -schema 由表结构定义生成)", template)
+		}
+		schema, err := core.ReadSchema(*schemaPath, *typeName)
+		if err != nil {
+			return fmt.Errorf("%s 读取失败: %v", *schemaPath, err)
+		}
+		if err := core.NewXlsxFromSchema(schema, template); err != nil {
+			return fmt.Errorf("%s 生成失败: %v", template, err)
+		}
+		created = true
+	}
+
+	result, err := core.ImportXlsx(src, template, out)
+	if err != nil {
+		if created {
+			os.Remove(template)
+		}
+		return fmt.Errorf("%s 导入失败: %v", src, err)
+	}
+	fmt.Printf("%s -> %s: 更新 %d 行，新增 %d 行，修改 %d 个单元格\n", src, out, result.Updated, result.Added, result.Cells)
+	for _, e := range result.Errors {
+		fmt.Println("  " + e)
+	}
+	return nil
+}
+
+// 指定了配置目录时加载共享类型
+func loadSharedTypes() error {
+	if len(core.GFlags.Path) == 0 && len(core.GFlags.Types) == 0 {
+		return nil
+	}
+	return core.LoadSharedTypes()
+}
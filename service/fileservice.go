@@ -32,6 +32,13 @@ type AppConfig struct {
 	I18nLang   string   `json:"i18n_lang"`
 	ServerFmts []string `json:"server_fmts"`
 	ClientFmts []string `json:"client_fmts"`
+
+	// 项目配置(excelparser.yaml)中的导出选项，只用于界面初始值
+	Compact     bool `json:"compact"`
+	Indent      bool `json:"indent"`
+	ErrorReport bool `json:"error_report"`
+
+	ProjectConfig string `json:"project_config,omitempty"` // 使用的项目配置文件(不保存)
 }
 
 type XlsxListItem struct {
@@ -47,30 +54,79 @@ type ExportProgressEvent struct {
 	Status   int      `json:"status"`   // 导出状态：0=空闲, 1=导出中, 2=成功, 3=失败, 4=跳过
 	Message  string   `json:"message"`  // 结果消息，成功时可为空，失败时包含错误信息
 	Messages []string `json:"messages"` // 所有错误消息列表
+	Report   string   `json:"report"`   // 错误报告工作簿路径(开启错误报告且导出失败时)
 	Seq      int64    `json:"seq"`      // 事件序列号，用于前端排序
 }
 
 //#region MARK: 配置管理
 
 // 加载配置
-func loadConfig() *AppConfig {
+// 项目配置(excelparser.yaml)作为默认值，界面保存的配置(.excelparser.json)中非空的项优先
+func loadConfig() (*AppConfig, error) {
 	config := &AppConfig{}
-	if data, err := os.ReadFile(configFileName); err == nil {
-		json.Unmarshal(data, config)
-
-		// 将配置应用到核心
-		core.GFlags.Path = config.ConfigPath
-		core.GFlags.Output = config.OutputPath
-		core.GFlags.I18nPath = config.I18nPath
-		core.GFlags.I18nLang = config.I18nLang
-		core.GFlags.Server = config.ServerFmts
-		core.GFlags.Client = config.ClientFmts
+	project, err := core.ApplyProjectConfig("", nil)
+	if err != nil {
+		return nil, fmt.Errorf("项目配置错误: %v", err)
+	}
+	if len(project) > 0 {
+		config = &AppConfig{
+			ConfigPath:  core.GFlags.Path,
+			OutputPath:  core.GFlags.Output,
+			I18nPath:    core.GFlags.I18nPath,
+			I18nLang:    core.GFlags.I18nLang,
+			ServerFmts:  core.GFlags.Server,
+			ClientFmts:  core.GFlags.Client,
+			Compact:     core.GFlags.Compact,
+			Indent:      core.GFlags.Pretty,
+			ErrorReport: core.GFlags.ErrorReport,
+
+			ProjectConfig: project,
+		}
+	}
+
+	data, err := os.ReadFile(configFileName)
+	if err != nil && len(project) == 0 {
+		return config, nil
+	}
+	if err == nil {
+		saved := &AppConfig{}
+		json.Unmarshal(data, saved)
+		fillConfig(config, saved)
+	}
+
+	// 将配置应用到核心
+	core.GFlags.Path = config.ConfigPath
+	core.GFlags.Output = config.OutputPath
+	core.GFlags.I18nPath = config.I18nPath
+	core.GFlags.I18nLang = config.I18nLang
+	core.GFlags.Server = config.ServerFmts
+	core.GFlags.Client = config.ClientFmts
+	return config, nil
+}
+
+// 使用界面保存的配置补充项目配置中未设置的项(项目配置优先)
+func fillConfig(config, saved *AppConfig) {
+	for _, v := range []struct{ dst, src *string }{
+		{&config.ConfigPath, &saved.ConfigPath},
+		{&config.OutputPath, &saved.OutputPath},
+		{&config.I18nPath, &saved.I18nPath},
+		{&config.I18nLang, &saved.I18nLang},
+	} {
+		if len(*v.dst) == 0 {
+			*v.dst = *v.src
+		}
+	}
+	if len(config.ServerFmts) == 0 {
+		config.ServerFmts = saved.ServerFmts
+	}
+	if len(config.ClientFmts) == 0 {
+		config.ClientFmts = saved.ClientFmts
 	}
-	return config
 }
 
 // 保存配置
 func saveConfig(config *AppConfig) error {
+	config.ProjectConfig = ""
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return err
@@ -162,7 +218,8 @@ type FileService struct {
 
 // 服务启动时加载配置
 func (s *FileService) ServiceStartup(ctx context.Context, options application.ServiceOptions) error {
-	s.config = loadConfig()
+	// 项目配置错误不影响启动，界面加载配置时提示
+	s.config, _ = loadConfig()
 	return nil
 }
 
@@ -171,7 +228,7 @@ func (f *FileService) SaveConfig(config *AppConfig) error {
 }
 
 func (f *FileService) GetConfig() (*AppConfig, error) {
-	return loadConfig(), nil
+	return loadConfig()
 }
 
 func (f *FileService) GetXlsxList(path string) ([]XlsxListItem, error) {
@@ -261,6 +318,9 @@ func (f *FileService) SetExportFlag(flagType int32, flagVal bool) {
 	case 3:
 		// 是否强制重新生成
 		core.GFlags.Force = flagVal
+	case 4:
+		// 导出失败时输出错误报告工作簿
+		core.GFlags.ErrorReport = flagVal
 	}
 }
 
@@ -325,6 +385,7 @@ func (f *FileService) StartExport() error {
 					Status:   status,
 					Message:  message,
 					Messages: messages,
+					Report:   event.Xlsx.ErrorReport,
 				})
 			}
 		},